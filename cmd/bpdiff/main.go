@@ -0,0 +1,100 @@
+// Command bpdiff compares two binary records field by field.
+//
+//	bpdiff -format '!,H,I,16s' -names opcode,length,name expected.bin actual.bin
+//
+// It prints one line per differing field and exits with status 1 when the
+// records differ, or 2 on error.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/eyotang/load/library/binarypack"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run is main with its arguments and output passed in; it returns the exit status.
+func run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("bpdiff", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	var (
+		format = flags.String("format", "", "comma separated format tokens, e.g. '!,H,I,16s'")
+		names  = flags.String("names", "", "comma separated names of the fields (optional)")
+		strict = flags.Bool("strict", false, "fail when a record is longer than the format")
+	)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: bpdiff -format TOKENS [-names NAMES] expected actual")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if *format == "" || flags.NArg() != 2 {
+		flags.Usage()
+		return 2
+	}
+
+	layout := layoutOf(strings.Split(*format, ","), *names)
+
+	expected, err := ioutil.ReadFile(flags.Arg(0))
+	if err != nil {
+		return fail(stderr, err)
+	}
+	actual, err := ioutil.ReadFile(flags.Arg(1))
+	if err != nil {
+		return fail(stderr, err)
+	}
+
+	bp := &binarypack.BinaryPack{Strict: *strict}
+	if *strict {
+		for _, msg := range [][]byte{expected, actual} {
+			if _, err = bp.UnPack(layout.Format(), msg); err != nil {
+				return fail(stderr, err)
+			}
+		}
+	}
+
+	diffs, err := bp.Diff(layout, expected, actual)
+	if err != nil {
+		return fail(stderr, err)
+	}
+	if len(diffs) > 0 {
+		fmt.Fprintln(stdout, diffs)
+		return 1
+	}
+	return 0
+}
+
+// layoutOf names the value fields of format in order; markers get no name.
+func layoutOf(format []string, names string) binarypack.Layout {
+	var (
+		l    binarypack.Layout
+		rest []string
+	)
+
+	if names != "" {
+		rest = strings.Split(names, ",")
+	}
+	for _, f := range format {
+		field := binarypack.Field{Format: f}
+		if !binarypack.IsOrderMarker(f) && len(rest) > 0 {
+			field.Name, rest = rest[0], rest[1:]
+		}
+		l = append(l, field)
+	}
+	return l
+}
+
+func fail(stderr io.Writer, err error) int {
+	fmt.Fprintln(stderr, "bpdiff:", err)
+	return 2
+}
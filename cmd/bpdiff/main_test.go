@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bpdiff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(name string, b []byte) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, b, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	expected := write("expected.bin", []byte{0, 1, 0, 0, 0, 16, 'D', 'U', 'M', 'P'})
+	actual := write("actual.bin", []byte{0, 2, 0, 0, 0, 16, 'D', 'U', 'M', 'P'})
+	longer := write("longer.bin", []byte{0, 1, 0, 0, 0, 16, 'D', 'U', 'M', 'P', 9})
+
+	type Case struct {
+		args   []string
+		status int
+		stdout string
+	}
+	cases := []Case{
+		{[]string{"-format", "!,H,I,4s", "-names", "opcode,length,name", expected, expected}, 0, ""},
+		{[]string{"-format", "!,H,I,4s", "-names", "opcode,length,name", expected, actual}, 1,
+			"field 0 (opcode) at offset 0: expected 1, actual 2\n"},
+		{[]string{"-format", "!,H,I,4s", expected, longer}, 1,
+			"field 3 at offset 10: expected []byte{}, actual []byte{0x9}\n"},
+		{[]string{"-format", "!,H,I,4s", "-strict", expected, longer}, 2, ""},
+		{[]string{"-format", "!,H,I,4s", expected, filepath.Join(dir, "missing.bin")}, 2, ""},
+		{[]string{"-format", "!,H,I,4s", expected}, 2, ""},
+		{[]string{expected, actual}, 2, ""},
+		{[]string{"-bogus"}, 2, ""},
+	}
+
+	Convey("TEST run", t, func() {
+		for _, c := range cases {
+			var stdout, stderr bytes.Buffer
+			So(run(c.args, &stdout, &stderr), ShouldEqual, c.status)
+			So(stdout.String(), ShouldEqual, c.stdout)
+			if c.status == 2 {
+				So(stderr.String(), ShouldNotBeEmpty)
+			}
+		}
+	})
+}
@@ -11,14 +11,29 @@
 		f - float32, packed size 4 bytes
 		d - float64, packed size 8 bytes
 		Ns - string, packed size N bytes, N is a number of runes to binarypack/unpack
+	Time fields combine an integer code (h, H, i, I, l, L, q, Q; lowercase codes are signed)
+	with a time spec after a colon and map to time.Time and time.Duration values:
+		X:T<unit>[@<epoch>] - time.Time counted in units since epoch, e.g. "I:Ts", "q:Tms", "Q:T100ns@windows"
+		X:D<unit> - time.Duration counted in units, e.g. "i:Dms"
+	Units are ns, 100ns, us, ms, s (the default), m and h. Epochs are unix (the default), windows
+	(1601-01-01, as used by FILETIME), ntp (1900-01-01), gps (1980-01-06) or a date in YYYY-MM-DD form.
+	Values that do not fit the integer code are reported as errors; times are truncated to the unit.
+	Byte order markers (a marker token applies to every field after it, little-endian is the default):
+		< - little-endian
+		>, ! - big-endian (network)
+		~ - PDP-endian: little-endian 16-bit words, most significant word first
+		^ - word-swapped: big-endian 16-bit words, least significant word first
+	Any marker may also prefix a single field token (e.g. ">H", "~I") to override the byte order
+	of that field only, without changing the order used by the following fields.
+	The format characters follow Python's struct module; COMPATIBILITY.md lists the differences.
 */
 
 package binarypack
 
 import (
-	"bytes"
 	"encoding/binary"
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 	"unicode/utf8"
@@ -26,7 +41,10 @@ import (
 	"github.com/pkg/errors"
 )
 
-type BinaryPack struct{}
+type BinaryPack struct {
+	// Strict makes UnPack reject messages longer than the format.
+	Strict bool
+}
 
 // Return a byte slice containing the values of msg slice packed according to the given format.
 // The items of msg slice must match the values required by the format exactly.
@@ -46,61 +64,75 @@ func (bp *BinaryPack) Pack(format []string, msg []interface{}) (res []byte, err
 	//	log.Error("type is: %T\n", t)
 	//}
 	order = binary.LittleEndian
-	for _, f := range format {
+	for _, token := range format {
+		if o, ok := orderMarker(token); ok {
+			order = o
+			continue
+		}
+
+		f, fo := fieldOrder(token, order)
 		switch f {
-		case "<":
-			order = binary.LittleEndian
-			i -= 1
-		case ">", "!":
-			order = binary.BigEndian
-			i -= 1
 		case "?":
 			casted_value, ok := msg[i].(bool)
 			if !ok {
-				err = errors.New("Type of passed value doesn't match to expected '" + f + "' (bool)")
+				err = errors.New("Type of passed value doesn't match to expected '" + token + "' (bool)")
 				return
 			}
-			res = append(res, boolToBytes(casted_value, order)...)
+			res = append(res, boolToBytes(casted_value, fo)...)
 		case "h", "H":
 			casted_value, ok := msg[i].(int64)
 			if !ok {
-				err = errors.New("Type of passed value doesn't match to expected '" + f + "' (int64, 2 bytes)")
+				err = errors.New("Type of passed value doesn't match to expected '" + token + "' (int64, 2 bytes)")
 				return
 			}
-			res = append(res, int64ToBytes(casted_value, 2, order)...)
+			res = append(res, int64ToBytes(casted_value, 2, fo)...)
 		case "i", "I", "l", "L":
 			casted_value, ok := msg[i].(int64)
 			if !ok {
-				err = errors.New("Type of passed value doesn't match to expected '" + f + "' (int64, 4 bytes)")
+				err = errors.New("Type of passed value doesn't match to expected '" + token + "' (int64, 4 bytes)")
 				return
 			}
-			res = append(res, int64ToBytes(casted_value, 4, order)...)
+			res = append(res, int64ToBytes(casted_value, 4, fo)...)
 		case "q", "Q":
 			casted_value, ok := msg[i].(int64)
 			if !ok {
-				err = errors.New("Type of passed value doesn't match to expected '" + f + "' (int64, 8 bytes)")
+				err = errors.New("Type of passed value doesn't match to expected '" + token + "' (int64, 8 bytes)")
 				return
 			}
-			res = append(res, int64ToBytes(casted_value, 8, order)...)
+			res = append(res, int64ToBytes(casted_value, 8, fo)...)
 		case "f":
 			casted_value, ok := msg[i].(float32)
 			if !ok {
-				err = errors.New("Type of passed value doesn't match to expected '" + f + "' (float32)")
+				err = errors.New("Type of passed value doesn't match to expected '" + token + "' (float32)")
 				return
 			}
-			res = append(res, float32ToBytes(casted_value, 4, order)...)
+			res = append(res, float32ToBytes(casted_value, fo)...)
 		case "d":
 			casted_value, ok := msg[i].(float64)
 			if !ok {
-				err = errors.New("Type of passed value doesn't match to expected '" + f + "' (float64)")
+				err = errors.New("Type of passed value doesn't match to expected '" + token + "' (float64)")
 				return
 			}
-			res = append(res, float64ToBytes(casted_value, 8, order)...)
+			res = append(res, float64ToBytes(casted_value, fo)...)
 		default:
-			if strings.Contains(f, "s") {
+			if strings.Contains(f, ":") {
+				var (
+					tc timeCode
+					b  []byte
+				)
+				if tc, err = parseTimeCode(f); err != nil {
+					err = errors.Wrap(err, "Unexpected format token: '"+token+"'")
+					return
+				}
+				if b, err = tc.pack(msg[i], fo); err != nil {
+					err = errors.Wrap(err, "Cannot binarypack value for '"+token+"'")
+					return
+				}
+				res = append(res, b...)
+			} else if strings.Contains(f, "s") {
 				casted_value, ok := msg[i].(string)
 				if !ok {
-					err = errors.New("Type of passed value doesn't match to expected '" + f + "' (string)")
+					err = errors.New("Type of passed value doesn't match to expected '" + token + "' (string)")
 					return
 				}
 				n, _ := strconv.Atoi(strings.TrimRight(f, "s"))
@@ -109,7 +141,7 @@ func (bp *BinaryPack) Pack(format []string, msg []interface{}) (res []byte, err
 				if n > l {
 					size = n - l
 				}
-				if order == binary.BigEndian {
+				if fo == binary.BigEndian {
 					res = append(res, []byte(fmt.Sprintf("%s%s",
 						strings.Repeat("\x00", size), reverse(casted_value[:n-size])))...)
 				} else {
@@ -117,7 +149,7 @@ func (bp *BinaryPack) Pack(format []string, msg []interface{}) (res []byte, err
 						casted_value[:n-size], strings.Repeat("\x00", size)))...)
 				}
 			} else {
-				err = errors.New("Unexpected format token: '" + f + "'")
+				err = errors.New("Unexpected format token: '" + token + "'")
 				return
 			}
 		}
@@ -147,45 +179,62 @@ func (bp *BinaryPack) UnPack(format []string, msg []byte) (res []interface{}, er
 	}
 
 	order = binary.LittleEndian
-	for _, f := range format {
+	for _, token := range format {
+		if o, ok := orderMarker(token); ok {
+			order = o
+			continue
+		}
+
+		f, fo := fieldOrder(token, order)
 		switch f {
-		case "<":
-			order = binary.LittleEndian
-		case ">", "!":
-			order = binary.BigEndian
 		case "?":
-			res = append(res, bytesToBool(msg[:1], order))
+			res = append(res, bytesToBool(msg[:1], fo))
 			msg = msg[1:]
 		case "h", "H":
-			res = append(res, bytesToInt64(msg[:2], order))
+			res = append(res, bytesToInt64(msg[:2], fo))
 			msg = msg[2:]
 		case "i", "I", "l", "L":
-			res = append(res, bytesToInt64(msg[:4], order))
+			res = append(res, bytesToInt64(msg[:4], fo))
 			msg = msg[4:]
 		case "q", "Q":
-			res = append(res, bytesToInt64(msg[:8], order))
+			res = append(res, bytesToInt64(msg[:8], fo))
 			msg = msg[8:]
 		case "f":
-			res = append(res, bytesToFloat32(msg[:4], order))
+			res = append(res, bytesToFloat32(msg[:4], fo))
 			msg = msg[4:]
 		case "d":
-			res = append(res, bytesToFloat64(msg[:8], order))
+			res = append(res, bytesToFloat64(msg[:8], fo))
 			msg = msg[8:]
 		default:
-			if strings.Contains(f, "s") {
+			if strings.Contains(f, ":") {
+				tc, err := parseTimeCode(f)
+				if err != nil {
+					return nil, errors.Wrap(err, "Unexpected format token: '"+token+"'")
+				}
+				v, err := tc.unpack(msg[:tc.size], fo)
+				if err != nil {
+					return nil, errors.Wrap(err, "Cannot unpack value for '"+token+"'")
+				}
+				res = append(res, v)
+				msg = msg[tc.size:]
+			} else if strings.Contains(f, "s") {
 				n, _ := strconv.Atoi(strings.TrimRight(f, "s"))
-				if order == binary.BigEndian {
+				if fo == binary.BigEndian {
 					res = append(res, strings.TrimRight(reverse(string(msg[:n])), "\x00"))
 				} else {
 					res = append(res, strings.TrimRight(string(msg[:n]), "\x00"))
 				}
 				msg = msg[n:]
 			} else {
-				return nil, errors.New("Unexpected format token: '" + f + "'")
+				return nil, errors.New("Unexpected format token: '" + token + "'")
 			}
 		}
 	}
 
+	if bp.Strict && len(msg) > 0 {
+		return nil, errors.Errorf("Message has %d bytes of trailing data", len(msg))
+	}
+
 	return res, nil
 }
 
@@ -193,10 +242,13 @@ func (bp *BinaryPack) UnPack(format []string, msg []byte) (res []interface{}, er
 func (bp *BinaryPack) CalcSize(format []string) (int, error) {
 	var size int
 
-	for _, f := range format {
+	for _, token := range format {
+		if _, ok := orderMarker(token); ok {
+			continue
+		}
+
+		f, _ := fieldOrder(token, nil)
 		switch f {
-		case "<", ">", "!":
-			size += 0
 		case "?":
 			size = size + 1
 		case "h", "H":
@@ -206,11 +258,17 @@ func (bp *BinaryPack) CalcSize(format []string) (int, error) {
 		case "q", "Q", "d":
 			size = size + 8
 		default:
-			if strings.Contains(f, "s") {
+			if strings.Contains(f, ":") {
+				tc, err := parseTimeCode(f)
+				if err != nil {
+					return 0, errors.Wrap(err, "Unexpected format token: '"+token+"'")
+				}
+				size = size + tc.size
+			} else if strings.Contains(f, "s") {
 				n, _ := strconv.Atoi(strings.TrimRight(f, "s"))
 				size = size + n
 			} else {
-				return 0, errors.New("Unexpected format token: '" + f + "'")
+				return 0, errors.New("Unexpected format token: '" + token + "'")
 			}
 		}
 	}
@@ -221,7 +279,7 @@ func (bp *BinaryPack) CalcSize(format []string) (int, error) {
 func formatLen(format []string) (length int) {
 	for _, f := range format {
 		switch f {
-		case "@", "=", "<", ">", "!":
+		case "@", "=", "<", ">", "!", "~", "^":
 			length += 0
 		default:
 			length++
@@ -243,67 +301,52 @@ func bytesToBool(b []byte, order binary.ByteOrder) bool {
 }
 
 func int64ToBytes(n int64, size int, order binary.ByteOrder) []byte {
-	buf := bytes.NewBuffer([]byte{})
-	binary.Write(buf, order, int64(n))
-	if order == binary.BigEndian {
-		return buf.Bytes()[8-size:]
+	b := make([]byte, size)
+
+	switch size {
+	case 1:
+		b[0] = byte(n)
+	case 2:
+		order.PutUint16(b, uint16(n))
+	case 4:
+		order.PutUint32(b, uint32(n))
+	default:
+		order.PutUint64(b, uint64(n))
 	}
-	return buf.Bytes()[0:size]
+	return b
 }
 
 func bytesToInt64(b []byte, order binary.ByteOrder) int64 {
-	buf := bytes.NewBuffer(b)
-
 	switch len(b) {
 	case 1:
-		var x int8
-		binary.Read(buf, order, &x)
-		return int64(x)
+		return int64(int8(b[0]))
 	case 2:
-		var x int16
-		binary.Read(buf, order, &x)
-		return int64(x)
+		return int64(int16(order.Uint16(b)))
 	case 4:
-		var x int32
-		binary.Read(buf, order, &x)
-		return int64(x)
+		return int64(int32(order.Uint32(b)))
 	default:
-		var x int64
-		binary.Read(buf, order, &x)
-		return int64(x)
+		return int64(order.Uint64(b))
 	}
 }
 
-func float32ToBytes(n float32, size int, order binary.ByteOrder) []byte {
-	buf := bytes.NewBuffer([]byte{})
-	binary.Write(buf, order, n)
-	if order == binary.BigEndian {
-		return buf.Bytes()[4-size:]
-	}
-	return buf.Bytes()[0:size]
+func float32ToBytes(n float32, order binary.ByteOrder) []byte {
+	b := make([]byte, 4)
+	order.PutUint32(b, math.Float32bits(n))
+	return b
 }
 
 func bytesToFloat32(b []byte, order binary.ByteOrder) float32 {
-	var x float32
-	buf := bytes.NewBuffer(b)
-	binary.Read(buf, order, &x)
-	return x
+	return math.Float32frombits(order.Uint32(b))
 }
 
-func float64ToBytes(n float64, size int, order binary.ByteOrder) []byte {
-	buf := bytes.NewBuffer([]byte{})
-	binary.Write(buf, order, n)
-	if order == binary.BigEndian {
-		return buf.Bytes()[8-size:]
-	}
-	return buf.Bytes()[0:size]
+func float64ToBytes(n float64, order binary.ByteOrder) []byte {
+	b := make([]byte, 8)
+	order.PutUint64(b, math.Float64bits(n))
+	return b
 }
 
 func bytesToFloat64(b []byte, order binary.ByteOrder) float64 {
-	var x float64
-	buf := bytes.NewBuffer(b)
-	binary.Read(buf, order, &x)
-	return x
+	return math.Float64frombits(order.Uint64(b))
 }
 
 func reverse(s string) string {
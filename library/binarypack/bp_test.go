@@ -208,3 +208,32 @@ func TestBinaryPack_UnPack(t *testing.T) {
 		}
 	})
 }
+
+func TestBinaryPack_Strict(t *testing.T) {
+	msg := []byte{1, 0, 0, 0, 2, 0, 0, 0, 4, 0, 0, 0, 68, 85, 77, 80}
+
+	Convey("TEST UnPack ignores trailing data by default", t, func() {
+		got, err := new(BinaryPack).UnPack([]string{"I", "I"}, msg)
+		So(err, ShouldBeNil)
+		So(got, ShouldResemble, []interface{}{int64(1), int64(2)})
+	})
+
+	Convey("TEST UnPack rejects trailing data in strict mode", t, func() {
+		bp := &BinaryPack{Strict: true}
+		_, err := bp.UnPack([]string{"I", "I"}, msg)
+		So(err, ShouldNotBeNil)
+
+		got, err := bp.UnPack([]string{"I", "I", "I", "4s"}, msg)
+		So(err, ShouldBeNil)
+		So(got, ShouldResemble, []interface{}{int64(1), int64(2), int64(4), "DUMP"})
+	})
+}
+
+func TestBinaryPack_UnPackSigned(t *testing.T) {
+	Convey("TEST UnPack sign-extends every integer code and boolean", t, func() {
+		got, err := new(BinaryPack).UnPack([]string{"H", "h", ">I", "L", "Q", "?", "?", "?"},
+			[]byte{255, 255, 255, 255, 255, 255, 255, 254, 0, 0, 0, 128, 255, 255, 255, 255, 255, 255, 255, 255, 128, 0, 1})
+		So(err, ShouldBeNil)
+		So(got, ShouldResemble, []interface{}{int64(-1), int64(-1), int64(-2), int64(-2147483648), int64(-1), false, false, true})
+	})
+}
@@ -0,0 +1,167 @@
+// Package bptest checks binarypack layouts with randomly generated records.
+//
+// For every record Check verifies that the values satisfy the constraints of the layout,
+// that Pack produces exactly CalcSize bytes and that UnPack gives the values back.
+// When a record fails, it is shrunk field by field towards zero values, empty strings and
+// the lower bounds of the constraints, and the smallest failing record is reported.
+package bptest
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/eyotang/load/library/binarypack"
+	"github.com/pkg/errors"
+)
+
+// Config tunes Check. The zero value is usable.
+type Config struct {
+	Iterations int   // number of random records, 100 if zero
+	MaxShrink  int   // number of shrinking steps, 1000 if zero
+	Seed       int64 // seed of the generator, time based if zero
+
+	// Property is an additional check run on every record after the round trip.
+	Property func(values []interface{}, packed []byte) error
+}
+
+// Failure is the smallest record found for which a check fails.
+type Failure struct {
+	Seed   int64
+	Values []interface{}
+	Packed []byte
+	Reason string
+}
+
+func (f *Failure) Error() string {
+	return fmt.Sprintf("record %#v (packed % x) fails: %s (seed %d)", f.Values, f.Packed, f.Reason, f.Seed)
+}
+
+// Check runs the round trip checks on random records of the layout and returns
+// a *Failure describing the smallest failing record, or nil.
+func Check(l binarypack.Layout, cfg *Config) error {
+	var c Config
+
+	if cfg != nil {
+		c = *cfg
+	}
+	if c.Iterations == 0 {
+		c.Iterations = 100
+	}
+	if c.MaxShrink == 0 {
+		c.MaxShrink = 1000
+	}
+	if c.Seed == 0 {
+		c.Seed = time.Now().UnixNano()
+	}
+
+	gens, err := generators(l)
+	if err != nil {
+		return err
+	}
+
+	r := rand.New(rand.NewSource(c.Seed))
+	for i := 0; i < c.Iterations; i++ {
+		values, err := generate(l, gens, r)
+		if err != nil {
+			return errors.Wrapf(err, "seed %d", c.Seed)
+		}
+		if reason := c.run(l, values); reason != "" {
+			return c.shrink(l, gens, values, reason)
+		}
+	}
+	return nil
+}
+
+// Run calls Check and fails the test on error.
+func Run(t testing.TB, l binarypack.Layout, cfg *Config) {
+	t.Helper()
+	if err := Check(l, cfg); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Generate returns a random record satisfying the constraints of the layout.
+func Generate(l binarypack.Layout, r *rand.Rand) ([]interface{}, error) {
+	gens, err := generators(l)
+	if err != nil {
+		return nil, err
+	}
+	return generate(l, gens, r)
+}
+
+// run checks one record and returns why it fails, or "".
+func (c *Config) run(l binarypack.Layout, values []interface{}) string {
+	bp := &binarypack.BinaryPack{Strict: true}
+
+	packed, err := bp.PackLayout(l, values)
+	if err != nil {
+		return "Pack: " + err.Error()
+	}
+	size, err := bp.CalcSize(l.Format())
+	if err != nil {
+		return "CalcSize: " + err.Error()
+	}
+	if size != len(packed) {
+		return fmt.Sprintf("CalcSize is %d but Pack produced %d bytes", size, len(packed))
+	}
+	got, err := bp.UnPackLayout(l, packed)
+	if err != nil {
+		return "UnPack: " + err.Error()
+	}
+	if !sameValues(got, values) {
+		return fmt.Sprintf("UnPack returned %#v", got)
+	}
+	if c.Property != nil {
+		if err = c.Property(values, packed); err != nil {
+			return err.Error()
+		}
+	}
+	return ""
+}
+
+// shrink greedily replaces fields by simpler values while the record keeps failing.
+func (c *Config) shrink(l binarypack.Layout, gens []generator, values []interface{}, reason string) error {
+	values = append([]interface{}{}, values...)
+
+	for steps, progress := 0, true; progress && steps < c.MaxShrink; {
+		progress = false
+		for i, g := range gens {
+			for _, v := range g.shrink(values[i]) {
+				steps++
+				candidate := append([]interface{}{}, values...)
+				candidate[i] = v
+				if !g.valid(v) {
+					continue
+				}
+				if r := c.run(l, candidate); r != "" {
+					values, reason, progress = candidate, r, true
+					break
+				}
+			}
+		}
+	}
+
+	packed, _ := new(binarypack.BinaryPack).Pack(l.Format(), values)
+	return &Failure{Seed: c.Seed, Values: values, Packed: packed, Reason: reason}
+}
+
+func sameValues(a, b []interface{}) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if ta, ok := a[i].(time.Time); ok {
+			if tb, ok := b[i].(time.Time); !ok || !ta.Equal(tb) {
+				return false
+			}
+			continue
+		}
+		if !reflect.DeepEqual(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,76 @@
+package bptest
+
+import (
+	"math/rand"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/eyotang/load/library/binarypack"
+	"github.com/pkg/errors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCheck(t *testing.T) {
+	layouts := []binarypack.Layout{
+		{{Format: "?"}, {Format: "h"}, {Format: "H"}, {Format: "i"}, {Format: "I"}, {Format: "q"}, {Format: "Q"}},
+		{{Format: "f"}, {Format: "d"}, {Format: "1s"}, {Format: "16s"}},
+		{{Format: "!"}, {Format: "H"}, {Format: "<I"}, {Format: "~q"}, {Format: "^d"}, {Format: "8s"}},
+		{{Format: "I:Ts"}, {Format: "q:Tms"}, {Format: "Q:T100ns@windows"}, {Format: "h:Dms"}, {Format: "Q:Dh"}},
+		{
+			{Name: "magic", Format: "!I", Magic: 0x4C4F4144},
+			{Name: "opcode", Format: "H", Enum: []interface{}{1, 2, 7}},
+			{Name: "length", Format: "H", Min: 1, Max: 512},
+			{Name: "ratio", Format: "f", Min: 0, Max: 1},
+			{Name: "sent", Format: "I:Ts", Min: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)},
+			{Name: "timeout", Format: "i:Dms", NonZero: true, Max: time.Minute},
+			{Name: "name", Format: "8s", UTF8: true, Pattern: regexp.MustCompile(`^[a-z]{2,6}(-[0-9])?$`)},
+		},
+	}
+
+	Convey("TEST Check of valid layouts", t, func() {
+		for _, l := range layouts {
+			So(Check(l, &Config{Seed: 1, Iterations: 200}), ShouldBeNil)
+		}
+	})
+
+	Convey("TEST Generate respects constraints", t, func() {
+		r := rand.New(rand.NewSource(1))
+		for i := 0; i < 50; i++ {
+			values, err := Generate(layouts[4], r)
+			So(err, ShouldBeNil)
+			So(layouts[4].Validate(values), ShouldBeNil)
+		}
+	})
+
+	Convey("TEST Check of unsatisfiable constraints", t, func() {
+		err := Check(binarypack.Layout{{Format: "H", Min: 10, Max: 5}}, &Config{Seed: 1})
+		So(err, ShouldNotBeNil)
+		So(err, ShouldNotHaveSameTypeAs, &Failure{})
+	})
+}
+
+func TestCheck_Shrink(t *testing.T) {
+	Convey("TEST Check shrinks failures to a minimal record", t, func() {
+		l := binarypack.Layout{{Format: "I"}, {Format: "8s"}, {Format: "?"}}
+		cfg := &Config{
+			Seed: 1,
+			Property: func(values []interface{}, packed []byte) error {
+				if values[0].(int64) > 100 && len(values[1].(string)) >= 2 {
+					return errors.New("too big")
+				}
+				return nil
+			},
+		}
+
+		err := Check(l, cfg)
+		So(err, ShouldHaveSameTypeAs, &Failure{})
+		f := err.(*Failure)
+		So(f.Seed, ShouldEqual, 1)
+		So(f.Reason, ShouldEqual, "too big")
+		So(f.Values[0], ShouldEqual, int64(101))
+		So(f.Values[1], ShouldHaveLength, 2)
+		So(f.Values[2], ShouldEqual, false)
+		So(f.Packed, ShouldHaveLength, 13)
+	})
+}
@@ -0,0 +1,378 @@
+package bptest
+
+import (
+	"math"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"regexp/syntax"
+	"strings"
+	"time"
+
+	"github.com/eyotang/load/library/binarypack"
+	"github.com/eyotang/load/library/binarypack/internal/number"
+	"github.com/pkg/errors"
+)
+
+// attempts bounds the retries spent generating one valid value.
+const attempts = 100
+
+// generator produces and shrinks the values of one field.
+type generator struct {
+	field binarypack.Field
+	info  binarypack.FieldInfo
+
+	lo, hi   int64   // bounds of the stored integer of Int, Time and Duration fields
+	flo, fhi float64 // bounds of float fields
+	re       *syntax.Regexp
+}
+
+func generators(l binarypack.Layout) (gens []generator, err error) {
+	for i, f := range l.Fields() {
+		var g generator
+		if g, err = newGenerator(f); err != nil {
+			return nil, errors.Wrapf(err, "field %d", i)
+		}
+		gens = append(gens, g)
+	}
+	return
+}
+
+func newGenerator(f binarypack.Field) (g generator, err error) {
+	g = generator{field: f}
+	if g.info, err = binarypack.Describe(f.Format); err != nil {
+		return
+	}
+
+	switch g.info.Kind {
+	case binarypack.Int, binarypack.Time, binarypack.Duration:
+		g.lo, g.hi = g.info.Min, g.info.Max
+		if g.info.Kind != binarypack.Int {
+			// keep the value of every stored integer inside time.Time and time.Duration
+			limit := int64(math.MaxInt64) / int64(g.info.Unit)
+			if g.info.Kind == binarypack.Time {
+				limit = int64(1<<62) / int64(math.Max(1, float64(g.info.Unit/time.Second)))
+			}
+			g.lo, g.hi = maxInt64(g.lo, -limit), minInt64(g.hi, limit)
+		}
+		if f.Min != nil {
+			g.lo = maxInt64(g.lo, g.ticks(f.Min, true))
+		}
+		if f.Max != nil {
+			g.hi = minInt64(g.hi, g.ticks(f.Max, false))
+		}
+	case binarypack.Float32, binarypack.Float64:
+		g.flo, g.fhi = -math.MaxFloat32, math.MaxFloat32
+		if g.info.Kind == binarypack.Float64 {
+			g.flo, g.fhi = -math.MaxFloat64, math.MaxFloat64
+		}
+		if x, ok := number.Float64(f.Min); ok {
+			g.flo = math.Max(g.flo, x)
+		}
+		if x, ok := number.Float64(f.Max); ok {
+			g.fhi = math.Min(g.fhi, x)
+		}
+	case binarypack.String:
+		if f.Pattern != nil {
+			var re *syntax.Regexp
+			if re, err = syntax.Parse(f.Pattern.String(), syntax.Perl); err != nil {
+				return
+			}
+			g.re = re.Simplify()
+		}
+	}
+	return
+}
+
+func generate(l binarypack.Layout, gens []generator, r *rand.Rand) ([]interface{}, error) {
+	values := make([]interface{}, 0, len(gens))
+
+	for i, g := range gens {
+		v, ok := g.random(r), false
+		for n := 1; n < attempts; n++ {
+			if ok = g.valid(v); ok {
+				break
+			}
+			v = g.random(r)
+		}
+		if !ok {
+			return nil, errors.Errorf("cannot generate a valid value for field %d (%s)", i, g.field.Format)
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// valid reports whether v satisfies the constraints of the field and packs without loss.
+func (g generator) valid(v interface{}) bool {
+	if s, ok := v.(string); ok && (len(s) > g.info.Size || strings.Contains(s, "\x00")) {
+		return false
+	}
+	if x, ok := v.(float64); ok && math.IsNaN(x) {
+		return false
+	}
+	if x, ok := v.(float32); ok && math.IsNaN(float64(x)) {
+		return false
+	}
+	return binarypack.Layout{g.field}.Validate([]interface{}{v}) == nil
+}
+
+func (g generator) random(r *rand.Rand) interface{} {
+	f := g.field
+
+	if f.Magic != nil {
+		return g.normalize(f.Magic)
+	}
+	if len(f.Enum) > 0 {
+		return g.normalize(f.Enum[r.Intn(len(f.Enum))])
+	}
+
+	switch g.info.Kind {
+	case binarypack.Bool:
+		return r.Intn(2) == 1
+	case binarypack.Int, binarypack.Time, binarypack.Duration:
+		return g.value(randInt64(r, g.lo, g.hi))
+	case binarypack.Float32:
+		return float32(randFloat64(r, g.flo, g.fhi))
+	case binarypack.Float64:
+		return randFloat64(r, g.flo, g.fhi)
+	}
+
+	var sb strings.Builder
+	if g.re != nil {
+		genRegexp(g.re, r, &sb)
+		return sb.String()
+	}
+	for n := r.Intn(g.info.Size + 1); n > 0; n-- {
+		sb.WriteByte(byte(' ' + r.Intn('~'-' '+1)))
+	}
+	return sb.String()
+}
+
+// shrink returns simpler candidates for v, simplest first.
+func (g generator) shrink(v interface{}) (candidates []interface{}) {
+	for _, e := range g.field.Enum {
+		e = g.normalize(e)
+		if reflect.DeepEqual(e, v) {
+			return
+		}
+		candidates = append(candidates, e)
+	}
+	if g.field.Magic != nil || len(g.field.Enum) > 0 {
+		return
+	}
+
+	switch x := v.(type) {
+	case bool:
+		if x {
+			candidates = append(candidates, false)
+		}
+	case string:
+		if x != "" {
+			candidates = append(candidates, "", x[:len(x)/2], x[:len(x)-1])
+		}
+	case float32:
+		for _, c := range shrinkFloat64(float64(x), g.flo, g.fhi) {
+			candidates = append(candidates, float32(c))
+		}
+	case float64:
+		for _, c := range shrinkFloat64(x, g.flo, g.fhi) {
+			candidates = append(candidates, c)
+		}
+	default:
+		n := g.ticks(v, false)
+		target := minInt64(maxInt64(0, g.lo), g.hi)
+		if n == target {
+			return
+		}
+		step := int64(1)
+		if n < target {
+			step = -1
+		}
+		for _, c := range []int64{target, n - (n-target)/2, n - step} {
+			candidates = append(candidates, g.value(c))
+		}
+	}
+	return
+}
+
+// value converts a stored integer to the value of the field.
+func (g generator) value(n int64) interface{} {
+	switch g.info.Kind {
+	case binarypack.Duration:
+		return time.Duration(n) * g.info.Unit
+	case binarypack.Time:
+		ns := new(big.Int).Mul(big.NewInt(n), big.NewInt(int64(g.info.Unit)))
+		sec, nsec := new(big.Int).DivMod(ns, big.NewInt(int64(time.Second)), new(big.Int))
+		epoch := g.info.Epoch
+		return time.Unix(epoch.Unix()+sec.Int64(), int64(epoch.Nanosecond())+nsec.Int64()).UTC()
+	}
+	return n
+}
+
+// ticks converts a value or bound of the field to a stored integer, rounding
+// up for lower bounds and down otherwise.
+func (g generator) ticks(v interface{}, up bool) int64 {
+	var ns *big.Int
+
+	switch x := v.(type) {
+	case time.Time:
+		epoch := g.info.Epoch
+		ns = new(big.Int).Mul(big.NewInt(x.Unix()-epoch.Unix()), big.NewInt(int64(time.Second)))
+		ns.Add(ns, big.NewInt(int64(x.Nanosecond()-epoch.Nanosecond())))
+	case time.Duration:
+		ns = big.NewInt(int64(x))
+	default:
+		if n, ok := number.Int64(v); ok {
+			return n
+		}
+		f, _ := number.Float64(v)
+		if up {
+			f = math.Ceil(f)
+		}
+		if f >= math.MaxInt64 {
+			return math.MaxInt64
+		}
+		return int64(math.Floor(f))
+	}
+
+	unit := big.NewInt(int64(g.info.Unit))
+	q, m := new(big.Int).DivMod(ns, unit, new(big.Int))
+	if up && m.Sign() != 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	if !q.IsInt64() {
+		if q.Sign() < 0 {
+			return math.MinInt64
+		}
+		return math.MaxInt64
+	}
+	return q.Int64()
+}
+
+// normalize converts a constant of a constraint to the Go type of the field.
+func (g generator) normalize(v interface{}) interface{} {
+	switch g.info.Kind {
+	case binarypack.Int:
+		if n, ok := number.Int64(v); ok {
+			return n
+		}
+	case binarypack.Float32:
+		if x, ok := number.Float64(v); ok {
+			return float32(x)
+		}
+	case binarypack.Float64:
+		if x, ok := number.Float64(v); ok {
+			return x
+		}
+	}
+	return v
+}
+
+// randInt64 favours the bounds and small numbers, which find most packing bugs.
+func randInt64(r *rand.Rand, lo, hi int64) int64 {
+	switch r.Intn(4) {
+	case 0:
+		return lo
+	case 1:
+		return hi
+	case 2:
+		if lo <= 256 && hi >= -256 {
+			return minInt64(maxInt64(int64(r.Intn(513)-256), lo), hi)
+		}
+	}
+
+	span := uint64(hi) - uint64(lo)
+	if span == math.MaxUint64 {
+		return int64(r.Uint64())
+	}
+	return int64(uint64(lo) + r.Uint64()%(span+1))
+}
+
+func randFloat64(r *rand.Rand, lo, hi float64) float64 {
+	switch r.Intn(4) {
+	case 0:
+		return lo
+	case 1:
+		return hi
+	case 2:
+		return math.Max(lo, math.Min(hi, float64(r.Intn(513)-256)/4))
+	}
+	// halved so that the span of the whole float64 range does not overflow
+	return (lo/2 + r.Float64()*(hi/2-lo/2)) * 2
+}
+
+func shrinkFloat64(x, lo, hi float64) (candidates []float64) {
+	for _, c := range []float64{math.Max(lo, math.Min(hi, 0)), math.Trunc(x), x / 2} {
+		if c != x && c >= lo && c <= hi {
+			candidates = append(candidates, c)
+		}
+	}
+	return
+}
+
+// genRegexp writes a random string matching re.
+func genRegexp(re *syntax.Regexp, r *rand.Rand, sb *strings.Builder) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		for _, c := range re.Rune {
+			sb.WriteRune(c)
+		}
+	case syntax.OpCharClass:
+		if len(re.Rune) == 0 {
+			return
+		}
+		i := r.Intn(len(re.Rune)/2) * 2
+		lo, hi := re.Rune[i], re.Rune[i+1]
+		if hi-lo > 0xff {
+			hi = lo + 0xff
+		}
+		sb.WriteRune(lo + rune(r.Intn(int(hi-lo)+1)))
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		sb.WriteByte(byte(' ' + r.Intn('~'-' '+1)))
+	case syntax.OpCapture:
+		genRegexp(re.Sub[0], r, sb)
+	case syntax.OpStar:
+		for n := r.Intn(4); n > 0; n-- {
+			genRegexp(re.Sub[0], r, sb)
+		}
+	case syntax.OpPlus:
+		for n := 1 + r.Intn(3); n > 0; n-- {
+			genRegexp(re.Sub[0], r, sb)
+		}
+	case syntax.OpQuest:
+		if r.Intn(2) == 1 {
+			genRegexp(re.Sub[0], r, sb)
+		}
+	case syntax.OpRepeat:
+		n := re.Min
+		if re.Max > re.Min {
+			n += r.Intn(re.Max - re.Min + 1)
+		} else if re.Max < 0 {
+			n += r.Intn(4)
+		}
+		for ; n > 0; n-- {
+			genRegexp(re.Sub[0], r, sb)
+		}
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			genRegexp(sub, r, sb)
+		}
+	case syntax.OpAlternate:
+		genRegexp(re.Sub[r.Intn(len(re.Sub))], r, sb)
+	}
+}
+
+func minInt64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,115 @@
+package binarypack
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// pythonVector is one entry of testdata/python_struct.json, see testdata/python_struct.py.
+type pythonVector struct {
+	Format    string   `json:"format"`
+	Tokens    []string `json:"tokens"`
+	Values    []string `json:"values"`
+	Bytes     string   `json:"bytes"`
+	Unpacked  []string `json:"unpacked"`
+	Error     bool     `json:"error"`
+	Deviation string   `json:"deviation"`
+}
+
+// pythonValues converts the text form of the values of a vector to the Go values of its tokens.
+// Integers above math.MaxInt64 are taken as their two's complement when wrap is set,
+// otherwise ok is false as they have no int64 counterpart.
+func pythonValues(tokens, values []string, wrap bool) (res []interface{}, ok bool) {
+	var codes []string
+	for _, token := range tokens {
+		if _, marker := orderMarker(token); !marker {
+			codes = append(codes, token)
+		}
+	}
+
+	for i, s := range values {
+		switch c := codes[i]; {
+		case c == "?":
+			res = append(res, s == "true")
+		case c == "f":
+			x, _ := strconv.ParseFloat(s, 32)
+			res = append(res, float32(x))
+		case c == "d":
+			x, _ := strconv.ParseFloat(s, 64)
+			res = append(res, x)
+		case strings.HasSuffix(c, "s"):
+			res = append(res, s)
+		default:
+			n, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				u, uerr := strconv.ParseUint(s, 10, 64)
+				if !wrap || uerr != nil {
+					return nil, false
+				}
+				n = int64(u)
+			}
+			res = append(res, n)
+		}
+	}
+	return res, true
+}
+
+func TestBinaryPack_PythonConformance(t *testing.T) {
+	var vectors []pythonVector
+
+	data, err := ioutil.ReadFile("testdata/python_struct.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = json.Unmarshal(data, &vectors); err != nil {
+		t.Fatal(err)
+	}
+
+	Convey("TEST Python struct conformance", t, func() {
+		deviations := map[string]int{}
+
+		for _, v := range vectors {
+			values, _ := pythonValues(v.Tokens, v.Values, true)
+			packed, err := new(BinaryPack).Pack(v.Tokens, values)
+
+			if v.Error {
+				// Python rejects the value; binarypack must only differ as documented
+				So(v.Deviation, ShouldEqual, "D4")
+				So(err, ShouldBeNil)
+				deviations[v.Deviation]++
+				continue
+			}
+			So(err, ShouldBeNil)
+
+			want, _ := hex.DecodeString(v.Bytes)
+			unpacked, err := new(BinaryPack).UnPack(v.Tokens, want)
+			So(err, ShouldBeNil)
+
+			expected, ok := pythonValues(v.Tokens, v.Unpacked, false)
+			conform := ok && reflect.DeepEqual(packed, want) && reflect.DeepEqual(unpacked, expected)
+			if v.Deviation == "" {
+				So(packed, ShouldResemble, want)
+				So(unpacked, ShouldResemble, expected)
+			} else {
+				// A vector marked as a deviation that conforms means COMPATIBILITY.md is out of date
+				So(conform, ShouldBeFalse)
+				deviations[v.Deviation]++
+			}
+		}
+
+		var report []string
+		for id, n := range deviations {
+			report = append(report, id+": "+strconv.Itoa(n)+" vector(s)")
+		}
+		sort.Strings(report)
+		t.Logf("%d vectors, documented deviations: %s", len(vectors), strings.Join(report, ", "))
+	})
+}
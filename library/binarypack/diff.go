@@ -0,0 +1,91 @@
+package binarypack
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Difference is a field whose value differs between two records.
+type Difference struct {
+	Index    int    // position of the value in the values of the layout
+	Name     string // name of the field, if any
+	Offset   int    // byte offset of the field
+	Expected interface{}
+	Actual   interface{}
+}
+
+func (d Difference) String() string {
+	field := fmt.Sprintf("field %d", d.Index)
+	if d.Name != "" {
+		field += " (" + d.Name + ")"
+	}
+	return fmt.Sprintf("%s at offset %d: expected %#v, actual %#v", field, d.Offset, d.Expected, d.Actual)
+}
+
+// Differences lists the fields that differ between two records, in layout order.
+type Differences []Difference
+
+func (ds Differences) String() string {
+	lines := make([]string, 0, len(ds))
+	for _, d := range ds {
+		lines = append(lines, d.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Diff decodes both byte slices with the same layout and returns the fields whose
+// bytes differ, with their decoded values. Fields compare by their bytes rather
+// than their values, as bools, NaNs, signed zeros and padded strings decode the
+// same from different bytes. Constraints are not checked. Bytes beyond the layout are compared
+// too and reported as a last, unnamed field holding the trailing data.
+func (bp *BinaryPack) Diff(l Layout, expected, actual []byte) (Differences, error) {
+	var (
+		diffs  Differences
+		fields = l.Fields()
+	)
+
+	size, err := bp.CalcSize(l.Format())
+	if err != nil {
+		return nil, err
+	}
+	offsets, sizes, err := l.spans()
+	if err != nil {
+		return nil, err
+	}
+
+	// Trailing data is compared below rather than rejected
+	lenient := BinaryPack{}
+	want, err := lenient.UnPack(l.Format(), expected)
+	if err != nil {
+		return nil, errors.Wrap(err, "expected")
+	}
+	got, err := lenient.UnPack(l.Format(), actual)
+	if err != nil {
+		return nil, errors.Wrap(err, "actual")
+	}
+
+	for i, f := range fields {
+		end := offsets[i] + sizes[i]
+		if string(expected[offsets[i]:end]) != string(actual[offsets[i]:end]) {
+			diffs = append(diffs, Difference{
+				Index:    i,
+				Name:     f.Name,
+				Offset:   offsets[i],
+				Expected: want[i],
+				Actual:   got[i],
+			})
+		}
+	}
+
+	if string(expected[size:]) != string(actual[size:]) {
+		diffs = append(diffs, Difference{
+			Index:    len(fields),
+			Offset:   size,
+			Expected: expected[size:],
+			Actual:   actual[size:],
+		})
+	}
+	return diffs, nil
+}
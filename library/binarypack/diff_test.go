@@ -0,0 +1,78 @@
+package binarypack
+
+import (
+	"math"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestBinaryPack_Diff(t *testing.T) {
+	layout := Layout{
+		{Format: "!"},
+		{Name: "opcode", Format: "H"},
+		{Name: "length", Format: "I"},
+		{Name: "name", Format: "4s"},
+		{Name: "ratio", Format: "f"},
+	}
+	golden, err := new(BinaryPack).Pack(layout.Format(), []interface{}{int64(1), int64(16), "DUMP", float32(0.5)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Convey("TEST Diff of identical records", t, func() {
+		diffs, err := new(BinaryPack).Diff(layout, golden, golden)
+		So(err, ShouldBeNil)
+		So(diffs, ShouldBeEmpty)
+	})
+
+	Convey("TEST Diff of NaN and signed zero fields", t, func() {
+		nan, _ := new(BinaryPack).Pack(layout.Format(), []interface{}{int64(1), int64(16), "DUMP", float32(math.NaN())})
+		diffs, err := new(BinaryPack).Diff(layout, nan, nan)
+		So(err, ShouldBeNil)
+		So(diffs, ShouldBeEmpty)
+
+		zero, _ := new(BinaryPack).Pack(layout.Format(), []interface{}{int64(1), int64(16), "DUMP", float32(0)})
+		negZero, _ := new(BinaryPack).Pack(layout.Format(), []interface{}{int64(1), int64(16), "DUMP", float32(math.Copysign(0, -1))})
+		diffs, err = new(BinaryPack).Diff(layout, zero, negZero)
+		So(err, ShouldBeNil)
+		So(diffs, ShouldHaveLength, 1)
+	})
+
+	Convey("TEST Diff of bool fields compares their bytes", t, func() {
+		flags := Layout{{Name: "flag", Format: "?"}}
+		for _, c := range [][2]byte{{0x01, 0x02}, {0x80, 0x00}} {
+			diffs, err := new(BinaryPack).Diff(flags, []byte{c[0]}, []byte{c[1]})
+			So(err, ShouldBeNil)
+			So(diffs, ShouldHaveLength, 1)
+			So(diffs[0].Name, ShouldEqual, "flag")
+		}
+	})
+
+	Convey("TEST Diff reports differing fields", t, func() {
+		actual, err := new(BinaryPack).Pack(layout.Format(), []interface{}{int64(2), int64(16), "DUMB", float32(0.5)})
+		So(err, ShouldBeNil)
+
+		diffs, err := new(BinaryPack).Diff(layout, golden, actual)
+		So(err, ShouldBeNil)
+		So(diffs, ShouldResemble, Differences{
+			{Index: 0, Name: "opcode", Offset: 0, Expected: int64(1), Actual: int64(2)},
+			{Index: 2, Name: "name", Offset: 6, Expected: "DUMP", Actual: "DUMB"},
+		})
+		So(diffs.String(), ShouldEqual, "field 0 (opcode) at offset 0: expected 1, actual 2\n"+
+			"field 2 (name) at offset 6: expected \"DUMP\", actual \"DUMB\"")
+	})
+
+	Convey("TEST Diff reports trailing data", t, func() {
+		diffs, err := new(BinaryPack).Diff(layout, golden, append(append([]byte{}, golden...), 9))
+		So(err, ShouldBeNil)
+		So(diffs, ShouldResemble, Differences{
+			{Index: 4, Offset: 14, Expected: []byte{}, Actual: []byte{9}},
+		})
+	})
+
+	Convey("TEST Diff of a short record", t, func() {
+		_, err := new(BinaryPack).Diff(layout, golden, golden[:4])
+		So(err, ShouldNotBeNil)
+	})
+}
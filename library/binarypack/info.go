@@ -0,0 +1,96 @@
+package binarypack
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Kind is the Go type of the values of a field.
+type Kind int
+
+const (
+	Bool     Kind = iota + 1 // bool
+	Int                      // int64
+	Float32                  // float32
+	Float64                  // float64
+	String                   // string
+	Time                     // time.Time
+	Duration                 // time.Duration
+)
+
+// FieldInfo describes how the values of a format token are packed.
+type FieldInfo struct {
+	Kind Kind
+	Size int // packed size in bytes
+
+	// Min and Max bound the integer stored by Int, Time and Duration fields,
+	// clamped to the range of int64.
+	Min, Max int64
+	// Unit and Epoch convert the stored integer of Time and Duration fields.
+	Unit  time.Duration
+	Epoch time.Time
+}
+
+// Describe returns how values of the format token are packed.
+// Byte order markers are not fields and are rejected.
+func Describe(token string) (info FieldInfo, err error) {
+	if _, ok := orderMarker(token); ok {
+		return info, errors.New("Byte order marker '" + token + "' is not a field")
+	}
+
+	f, _ := fieldOrder(token, nil)
+	switch f {
+	case "?":
+		return FieldInfo{Kind: Bool, Size: 1}, nil
+	case "h", "H":
+		return intInfo(2), nil
+	case "i", "I", "l", "L":
+		return intInfo(4), nil
+	case "q", "Q":
+		return intInfo(8), nil
+	case "f":
+		return FieldInfo{Kind: Float32, Size: 4}, nil
+	case "d":
+		return FieldInfo{Kind: Float64, Size: 8}, nil
+	}
+
+	if strings.Contains(f, ":") {
+		var tc timeCode
+		if tc, err = parseTimeCode(f); err != nil {
+			return info, errors.Wrap(err, "Unexpected format token: '"+token+"'")
+		}
+		min, max := tc.limits()
+		info = FieldInfo{Kind: Time, Size: tc.size, Unit: tc.unit, Epoch: tc.epoch}
+		if tc.duration {
+			info.Kind = Duration
+		}
+		info.Min, info.Max = math.MinInt64, math.MaxInt64
+		if min.IsInt64() {
+			info.Min = min.Int64()
+		}
+		if max.IsInt64() {
+			info.Max = max.Int64()
+		}
+		return info, nil
+	}
+	if strings.Contains(f, "s") {
+		n, _ := strconv.Atoi(strings.TrimRight(f, "s"))
+		return FieldInfo{Kind: String, Size: n}, nil
+	}
+	return info, errors.New("Unexpected format token: '" + token + "'")
+}
+
+// intInfo describes an integer field: UnPack sign-extends every integer code.
+func intInfo(size int) FieldInfo {
+	bits := uint(size*8 - 1)
+	return FieldInfo{
+		Kind: Int,
+		Size: size,
+		Min:  -1 << bits,
+		Max:  1<<bits - 1,
+	}
+}
@@ -0,0 +1,42 @@
+package binarypack
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDescribe(t *testing.T) {
+	type Case struct {
+		in   string
+		want FieldInfo
+	}
+	cases := []Case{
+		{"?", FieldInfo{Kind: Bool, Size: 1}},
+		{">H", FieldInfo{Kind: Int, Size: 2, Min: math.MinInt16, Max: math.MaxInt16}},
+		{"i", FieldInfo{Kind: Int, Size: 4, Min: math.MinInt32, Max: math.MaxInt32}},
+		{"Q", FieldInfo{Kind: Int, Size: 8, Min: math.MinInt64, Max: math.MaxInt64}},
+		{"f", FieldInfo{Kind: Float32, Size: 4}},
+		{"d", FieldInfo{Kind: Float64, Size: 8}},
+		{"12s", FieldInfo{Kind: String, Size: 12}},
+		{"I:Tms", FieldInfo{Kind: Time, Size: 4, Max: math.MaxUint32, Unit: time.Millisecond, Epoch: time.Unix(0, 0).UTC()}},
+		{"Q:T100ns@windows", FieldInfo{Kind: Time, Size: 8, Max: math.MaxInt64, Unit: 100,
+			Epoch: time.Date(1601, 1, 1, 0, 0, 0, 0, time.UTC)}},
+		{"h:D", FieldInfo{Kind: Duration, Size: 2, Min: math.MinInt16, Max: math.MaxInt16, Unit: time.Second}},
+	}
+
+	Convey("TEST Describe", t, func() {
+		for _, c := range cases {
+			got, err := Describe(c.in)
+			So(err, ShouldBeNil)
+			So(got, ShouldResemble, c.want)
+		}
+
+		for _, in := range []string{"!", "a", "q:X"} {
+			_, err := Describe(in)
+			So(err, ShouldNotBeNil)
+		}
+	})
+}
@@ -0,0 +1,53 @@
+// Package number converts the numbers of constraints and values, which may be
+// of any Go integer or float type, for binarypack and its test helpers.
+package number
+
+import (
+	"math"
+	"reflect"
+	"time"
+)
+
+// Int64 converts an integer that fits int64. time.Duration is not a number
+// here: it only compares with other durations.
+func Int64(v interface{}) (int64, bool) {
+	if _, ok := v.(time.Duration); ok {
+		return 0, false
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if u := rv.Uint(); u <= math.MaxInt64 {
+			return int64(u), true
+		}
+	}
+	return 0, false
+}
+
+// Uint64 converts unsigned integers, which Int64 rejects above MaxInt64.
+func Uint64(v interface{}) (uint64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint(), true
+	}
+	return 0, false
+}
+
+// Float64 converts any integer or float.
+func Float64(v interface{}) (float64, bool) {
+	if i, ok := Int64(v); ok {
+		return float64(i), true
+	}
+	if u, ok := Uint64(v); ok {
+		return float64(u), true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
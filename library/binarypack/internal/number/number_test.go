@@ -0,0 +1,45 @@
+package number
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestConvert(t *testing.T) {
+	type Case struct {
+		v   interface{}
+		i   int64
+		iok bool
+		u   uint64
+		uok bool
+		f   float64
+		fok bool
+	}
+	cases := []Case{
+		{int8(-3), -3, true, 0, false, -3, true},
+		{uint16(7), 7, true, 7, true, 7, true},
+		{uint64(math.MaxInt64), math.MaxInt64, true, math.MaxInt64, true, math.MaxInt64, true},
+		{uint64(1 << 63), 0, false, 1 << 63, true, 1 << 63, true},
+		{float32(1.5), 0, false, 0, false, 1.5, true},
+		{time.Second, 0, false, 0, false, 0, false},
+		{"1", 0, false, 0, false, 0, false},
+		{nil, 0, false, 0, false, 0, false},
+	}
+
+	Convey("TEST Int64, Uint64 and Float64", t, func() {
+		for _, c := range cases {
+			i, ok := Int64(c.v)
+			So(ok, ShouldEqual, c.iok)
+			So(i, ShouldEqual, c.i)
+			u, ok := Uint64(c.v)
+			So(ok, ShouldEqual, c.uok)
+			So(u, ShouldEqual, c.u)
+			f, ok := Float64(c.v)
+			So(ok, ShouldEqual, c.fok)
+			So(f, ShouldEqual, c.f)
+		}
+	})
+}
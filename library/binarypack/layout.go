@@ -0,0 +1,182 @@
+package binarypack
+
+import (
+	"regexp"
+
+	"github.com/pkg/errors"
+)
+
+// Field describes one entry of a Layout: a format token (see the package
+// documentation) and the constraints its value must satisfy.
+// A field whose format is a byte order marker carries no value.
+type Field struct {
+	Name   string
+	Format string
+
+	Min     interface{}    // inclusive lower bound for numbers, times and durations
+	Max     interface{}    // inclusive upper bound for numbers, times and durations
+	Enum    []interface{}  // the value must equal one of these
+	NonZero bool           // the value must not be the zero value of its type
+	UTF8    bool           // strings must be valid UTF-8
+	Pattern *regexp.Regexp // strings must match
+	Magic   interface{}    // the value must equal this constant
+
+	// Since is the layout version that added the field; fields of version 0
+	// are required, later ones are optional when decoding older records.
+	Since int
+	// Default is used for an optional field missing from a shorter record;
+	// nil means the value of an all-zero field.
+	Default interface{}
+}
+
+// Layout is an ordered list of fields describing a binary record.
+type Layout []Field
+
+// Version returns the layout as it was at version v, that is without the
+// fields added after it.
+func (l Layout) Version(v int) Layout {
+	var res Layout
+	for _, f := range l {
+		if f.Since <= v {
+			res = append(res, f)
+		}
+	}
+	return res
+}
+
+// Format returns the format tokens of the layout, as accepted by Pack, UnPack and CalcSize.
+func (l Layout) Format() []string {
+	format := make([]string, 0, len(l))
+	for _, f := range l {
+		format = append(format, f.Format)
+	}
+	return format
+}
+
+// Fields returns the fields of the layout that carry a value, leaving out byte order markers.
+func (l Layout) Fields() []Field {
+	fields := make([]Field, 0, len(l))
+	for _, f := range l {
+		if _, ok := orderMarker(f.Format); !ok {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// head returns the layout up to, but not including, its n-th value.
+func (l Layout) head(n int) Layout {
+	var res Layout
+	for _, f := range l {
+		if _, ok := orderMarker(f.Format); !ok {
+			if n == 0 {
+				break
+			}
+			n--
+		}
+		res = append(res, f)
+	}
+	return res
+}
+
+// spans returns the byte offset and the size of every value of the layout.
+func (l Layout) spans() (offsets, sizes []int, err error) {
+	var (
+		bp     BinaryPack
+		offset int
+		size   int
+	)
+
+	for _, f := range l {
+		if _, ok := orderMarker(f.Format); ok {
+			continue
+		}
+		if size, err = bp.CalcSize([]string{f.Format}); err != nil {
+			return nil, nil, err
+		}
+		offsets = append(offsets, offset)
+		sizes = append(sizes, size)
+		offset += size
+	}
+	return
+}
+
+// defaultValue returns the value decoded for the field when it is missing.
+func (f Field) defaultValue() (interface{}, error) {
+	if f.Default != nil {
+		return f.Default, nil
+	}
+
+	var bp BinaryPack
+	size, err := bp.CalcSize([]string{f.Format})
+	if err != nil {
+		return nil, err
+	}
+	values, err := bp.UnPack([]string{f.Format}, make([]byte, size))
+	if err != nil {
+		return nil, err
+	}
+	return values[0], nil
+}
+
+// Pack the values of msg according to the layout and check them against its constraints.
+// All constraint violations are returned together as a *ValidationError.
+func (bp *BinaryPack) PackLayout(l Layout, msg []interface{}) (res []byte, err error) {
+	if res, err = bp.Pack(l.Format(), msg); err != nil {
+		return nil, err
+	}
+	if err = l.Validate(msg); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// Unpack the byte slice according to the layout and check the values against its constraints.
+// All constraint violations are returned together as a *ValidationError.
+// A record written with an older version of the layout may end early: the optional
+// fields it lacks (Since > 0) get their default values, but it must not end inside a field.
+func (bp *BinaryPack) UnPackLayout(l Layout, msg []byte) (res []interface{}, err error) {
+	var (
+		fields  = l.Fields()
+		offsets []int
+		sizes   []int
+		present = len(fields)
+	)
+
+	if offsets, sizes, err = l.spans(); err != nil {
+		return nil, err
+	}
+	for i := range fields {
+		if offsets[i]+sizes[i] > len(msg) {
+			present = i
+			break
+		}
+	}
+
+	if present < len(fields) {
+		if offsets[present] != len(msg) {
+			return nil, errors.Errorf("Message ends inside field %d at offset %d", present, offsets[present])
+		}
+		for i := present; i < len(fields); i++ {
+			if fields[i].Since == 0 {
+				return nil, errors.Errorf("Expected size is bigger than actual size of message: field %d is required", i)
+			}
+		}
+	}
+
+	if res, err = bp.UnPack(l.head(present).Format(), msg); err != nil {
+		return nil, err
+	}
+	for _, f := range fields[present:] {
+		var v interface{}
+		if v, err = f.defaultValue(); err != nil {
+			return nil, err
+		}
+		res = append(res, v)
+	}
+
+	if err = l.Validate(res); err != nil {
+		return nil, err
+	}
+	return
+}
@@ -0,0 +1,115 @@
+package binarypack
+
+import (
+	"regexp"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLayout(t *testing.T) {
+	layout := Layout{
+		{Format: "!"},
+		{Name: "magic", Format: "H", Magic: int64(0x1234)},
+		{Name: "opcode", Format: "H", Enum: []interface{}{1, 2, 7}},
+		{Name: "length", Format: "<I", Min: 1, Max: 512},
+		{Name: "ratio", Format: "f", Min: 0, Max: 1.5},
+		{Name: "name", Format: "4s", NonZero: true, UTF8: true, Pattern: regexp.MustCompile("^[a-z]+$")},
+	}
+
+	Convey("TEST Layout Format", t, func() {
+		So(layout.Format(), ShouldResemble, []string{"!", "H", "H", "<I", "f", "4s"})
+	})
+
+	Convey("TEST PackLayout and UnPackLayout with valid values", t, func() {
+		values := []interface{}{int64(0x1234), int64(7), int64(512), float32(0.5), "dump"}
+		b, err := new(BinaryPack).PackLayout(layout, values)
+		So(err, ShouldBeNil)
+		So(b, ShouldResemble, []byte{18, 52, 0, 7, 0, 2, 0, 0, 63, 0, 0, 0, 112, 109, 117, 100})
+
+		got, err := new(BinaryPack).UnPackLayout(layout, b)
+		So(err, ShouldBeNil)
+		So(got, ShouldResemble, values)
+	})
+
+	Convey("TEST PackLayout reports every violation", t, func() {
+		values := []interface{}{int64(0x4321), int64(3), int64(0), float32(2), "D\xffX"}
+		_, err := new(BinaryPack).PackLayout(layout, values)
+		So(err, ShouldHaveSameTypeAs, &ValidationError{})
+
+		violations := err.(*ValidationError).Violations
+		So(violations, ShouldHaveLength, 6)
+		So(violations[0], ShouldResemble, Violation{Index: 0, Name: "magic", Offset: 0, Rule: "magic=4660", Value: int64(0x4321)})
+		So(violations[1], ShouldResemble, Violation{Index: 1, Name: "opcode", Offset: 2, Rule: "enum=1|2|7", Value: int64(3)})
+		So(violations[2], ShouldResemble, Violation{Index: 2, Name: "length", Offset: 4, Rule: "min=1", Value: int64(0)})
+		So(violations[3], ShouldResemble, Violation{Index: 3, Name: "ratio", Offset: 8, Rule: "max=1.5", Value: float32(2)})
+		So(violations[4].Rule, ShouldEqual, "utf8")
+		So(violations[4].Offset, ShouldEqual, 12)
+		So(violations[5].Rule, ShouldEqual, "pattern=^[a-z]+$")
+	})
+
+	Convey("TEST UnPackLayout reports violations of decoded values", t, func() {
+		_, err := new(BinaryPack).UnPackLayout(layout, []byte{18, 52, 0, 1, 0, 2, 0, 0, 63, 0, 0, 0, 0, 0, 0, 0})
+		So(err, ShouldHaveSameTypeAs, &ValidationError{})
+		violations := err.(*ValidationError).Violations
+		So(violations, ShouldHaveLength, 2)
+		So(violations[0].Rule, ShouldEqual, "nonzero")
+		So(violations[1].Rule, ShouldEqual, "pattern=^[a-z]+$")
+		So(err.Error(), ShouldContainSubstring, "field 4 (name) at offset 12")
+	})
+
+	Convey("TEST PackLayout keeps type errors", t, func() {
+		_, err := new(BinaryPack).PackLayout(layout, []interface{}{1, 2, 3, 4, 5})
+		So(err, ShouldNotBeNil)
+		So(err, ShouldNotHaveSameTypeAs, &ValidationError{})
+	})
+}
+
+func TestLayout_Versions(t *testing.T) {
+	layout := Layout{
+		{Name: "opcode", Format: "H"},
+		{Name: "length", Format: "H"},
+		{Name: "flags", Format: "H", Since: 1, Default: int64(3)},
+		{Name: "name", Format: "4s", Since: 2},
+		{Name: "ratio", Format: "f", Since: 2, Max: 1},
+	}
+
+	Convey("TEST Layout Version", t, func() {
+		So(layout.Version(0).Format(), ShouldResemble, []string{"H", "H"})
+		So(layout.Version(1).Format(), ShouldResemble, []string{"H", "H", "H"})
+		So(layout.Version(2).Format(), ShouldResemble, layout.Format())
+	})
+
+	Convey("TEST UnPackLayout fills defaults of fields missing from older records", t, func() {
+		v0, err := new(BinaryPack).PackLayout(layout.Version(0), []interface{}{int64(1), int64(2)})
+		So(err, ShouldBeNil)
+		got, err := new(BinaryPack).UnPackLayout(layout, v0)
+		So(err, ShouldBeNil)
+		So(got, ShouldResemble, []interface{}{int64(1), int64(2), int64(3), "", float32(0)})
+
+		v1, err := new(BinaryPack).PackLayout(layout.Version(1), []interface{}{int64(1), int64(2), int64(8)})
+		So(err, ShouldBeNil)
+		got, err = new(BinaryPack).UnPackLayout(layout, v1)
+		So(err, ShouldBeNil)
+		So(got, ShouldResemble, []interface{}{int64(1), int64(2), int64(8), "", float32(0)})
+	})
+
+	Convey("TEST UnPackLayout of shortened records", t, func() {
+		// Required fields cannot be missing
+		_, err := new(BinaryPack).UnPackLayout(layout, []byte{1, 0})
+		So(err, ShouldNotBeNil)
+		// Records cannot end inside a field
+		_, err = new(BinaryPack).UnPackLayout(layout, []byte{1, 0, 2, 0, 8})
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("TEST UnPackLayout rejects trailing data in strict mode", t, func() {
+		msg := []byte{1, 0, 2, 0, 8, 0, 100, 117, 109, 112, 0, 0, 0, 0, 9}
+		_, err := new(BinaryPack).UnPackLayout(layout, msg)
+		So(err, ShouldBeNil)
+		_, err = (&BinaryPack{Strict: true}).UnPackLayout(layout, msg)
+		So(err, ShouldNotBeNil)
+		_, err = (&BinaryPack{Strict: true}).UnPackLayout(layout, msg[:len(msg)-1])
+		So(err, ShouldBeNil)
+	})
+}
@@ -0,0 +1,97 @@
+package binarypack
+
+import "encoding/binary"
+
+var (
+	// PDPEndian is the middle-endian layout used by the PDP-11: 16-bit words are
+	// little-endian and the most significant word comes first (0x0A0B0C0D is
+	// stored as 0B 0A 0D 0C).
+	PDPEndian binary.ByteOrder = wordSwapped{binary.BigEndian, "PDPEndian"}
+
+	// WordSwappedEndian stores big-endian 16-bit words with the least significant
+	// word first (0x0A0B0C0D is stored as 0C 0D 0A 0B), as many Modbus devices do.
+	WordSwappedEndian binary.ByteOrder = wordSwapped{binary.LittleEndian, "WordSwappedEndian"}
+)
+
+// byteOrders maps byte order markers to the order they select.
+var byteOrders = map[byte]binary.ByteOrder{
+	'<': binary.LittleEndian,
+	'>': binary.BigEndian,
+	'!': binary.BigEndian,
+	'~': PDPEndian,
+	'^': WordSwappedEndian,
+}
+
+// orderMarker reports whether token is a stand-alone byte order marker
+// and returns the order it selects.
+func orderMarker(token string) (binary.ByteOrder, bool) {
+	if len(token) != 1 {
+		return nil, false
+	}
+	order, ok := byteOrders[token[0]]
+	return order, ok
+}
+
+// IsOrderMarker reports whether token is a byte order marker ("<", ">",
+// "!", "~" or "^") rather than a field.
+func IsOrderMarker(token string) bool {
+	_, ok := orderMarker(token)
+	return ok
+}
+
+// fieldOrder splits an optional per-field byte order prefix off token.
+// Without a prefix the field uses the current order.
+func fieldOrder(token string, current binary.ByteOrder) (string, binary.ByteOrder) {
+	if len(token) > 1 {
+		if order, ok := byteOrders[token[0]]; ok {
+			return token[1:], order
+		}
+	}
+	return token, current
+}
+
+// wordSwapped is a byte order made of 16-bit words: the bytes of base are
+// produced first and then the two bytes of every word are exchanged.
+type wordSwapped struct {
+	base binary.ByteOrder
+	name string
+}
+
+func swapWords(b []byte) []byte {
+	s := make([]byte, len(b))
+	for i := 0; i+1 < len(b); i += 2 {
+		s[i], s[i+1] = b[i+1], b[i]
+	}
+	return s
+}
+
+func (o wordSwapped) Uint16(b []byte) uint16 {
+	return o.base.Uint16(swapWords(b[:2]))
+}
+
+func (o wordSwapped) Uint32(b []byte) uint32 {
+	return o.base.Uint32(swapWords(b[:4]))
+}
+
+func (o wordSwapped) Uint64(b []byte) uint64 {
+	return o.base.Uint64(swapWords(b[:8]))
+}
+
+func (o wordSwapped) PutUint16(b []byte, v uint16) {
+	o.base.PutUint16(b, v)
+	copy(b, swapWords(b[:2]))
+}
+
+func (o wordSwapped) PutUint32(b []byte, v uint32) {
+	o.base.PutUint32(b, v)
+	copy(b, swapWords(b[:4]))
+}
+
+func (o wordSwapped) PutUint64(b []byte, v uint64) {
+	o.base.PutUint64(b, v)
+	copy(b, swapWords(b[:8]))
+}
+
+func (o wordSwapped) String() string {
+	return o.name
+}
@@ -0,0 +1,91 @@
+package binarypack
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestWordSwappedOrders(t *testing.T) {
+	Convey("TEST PDPEndian and WordSwappedEndian", t, func() {
+		b := make([]byte, 8)
+
+		PDPEndian.PutUint32(b, 0x0A0B0C0D)
+		So(b[:4], ShouldResemble, []byte{0x0B, 0x0A, 0x0D, 0x0C})
+		So(PDPEndian.Uint32(b), ShouldEqual, 0x0A0B0C0D)
+
+		WordSwappedEndian.PutUint32(b, 0x0A0B0C0D)
+		So(b[:4], ShouldResemble, []byte{0x0C, 0x0D, 0x0A, 0x0B})
+		So(WordSwappedEndian.Uint32(b), ShouldEqual, 0x0A0B0C0D)
+
+		PDPEndian.PutUint64(b, 0x0102030405060708)
+		So(b, ShouldResemble, []byte{2, 1, 4, 3, 6, 5, 8, 7})
+		So(PDPEndian.Uint64(b), ShouldEqual, 0x0102030405060708)
+
+		WordSwappedEndian.PutUint64(b, 0x0102030405060708)
+		So(b, ShouldResemble, []byte{7, 8, 5, 6, 3, 4, 1, 2})
+		So(WordSwappedEndian.Uint64(b), ShouldEqual, 0x0102030405060708)
+
+		PDPEndian.PutUint16(b, 0x0102)
+		So(b[:2], ShouldResemble, []byte{2, 1})
+		WordSwappedEndian.PutUint16(b, 0x0102)
+		So(b[:2], ShouldResemble, []byte{1, 2})
+	})
+}
+
+func TestBinaryPack_FieldOrder(t *testing.T) {
+	type Case struct {
+		f    []string
+		a    []interface{}
+		want []byte
+	}
+	cases := []Case{
+		// Per-field overrides do not change the order of the following fields
+		{[]string{">H", "H"}, []interface{}{int64(1), int64(1)}, []byte{0, 1, 1, 0}},
+		{[]string{">", "<H", "H"}, []interface{}{int64(1), int64(1)}, []byte{1, 0, 0, 1}},
+		{[]string{"!I", "<I", "~I", "^I"},
+			[]interface{}{int64(0x0A0B0C0D), int64(0x0A0B0C0D), int64(0x0A0B0C0D), int64(0x0A0B0C0D)},
+			[]byte{10, 11, 12, 13, 13, 12, 11, 10, 11, 10, 13, 12, 12, 13, 10, 11}},
+		// Big-endian header with little-endian payload and a PDP timestamp
+		{[]string{"!", "H", "H", "<i", "~I", "<4s"},
+			[]interface{}{int64(2), int64(16), int64(-2), int64(0x11223344), "DUMP"},
+			[]byte{0, 2, 0, 16, 254, 255, 255, 255, 0x22, 0x11, 0x44, 0x33, 68, 85, 77, 80}},
+		{[]string{"~", "q", "f"}, []interface{}{int64(0x0102030405060708), float32(5.3)},
+			[]byte{2, 1, 4, 3, 6, 5, 8, 7, 169, 64, 154, 153}},
+		{[]string{"^d"}, []interface{}{5.3}, []byte{51, 51, 51, 51, 51, 51, 64, 21}},
+	}
+
+	Convey("TEST Pack and UnPack with per-field byte order", t, func() {
+		for _, c := range cases {
+			got, err := new(BinaryPack).Pack(c.f, c.a)
+			So(err, ShouldBeNil)
+			So(got, ShouldResemble, c.want)
+
+			size, err := new(BinaryPack).CalcSize(c.f)
+			So(err, ShouldBeNil)
+			So(size, ShouldEqual, len(c.want))
+
+			values, err := new(BinaryPack).UnPack(c.f, c.want)
+			So(err, ShouldBeNil)
+			So(values, ShouldResemble, c.a)
+		}
+	})
+
+	Convey("TEST per-field byte order on unknown token", t, func() {
+		_, err := new(BinaryPack).CalcSize([]string{">a"})
+		So(err, ShouldNotBeNil)
+		_, err = new(BinaryPack).Pack([]string{"~z"}, []interface{}{int64(1)})
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestIsOrderMarker(t *testing.T) {
+	Convey("TEST IsOrderMarker", t, func() {
+		for _, m := range []string{"<", ">", "!", "~", "^"} {
+			So(IsOrderMarker(m), ShouldBeTrue)
+		}
+		for _, f := range []string{"H", ">H", "4s", "", "@"} {
+			So(IsOrderMarker(f), ShouldBeFalse)
+		}
+	})
+}
@@ -0,0 +1,253 @@
+package binarypack
+
+import (
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eyotang/load/library/binarypack/internal/number"
+	"github.com/pkg/errors"
+)
+
+// LayoutOf builds a Layout from the `bp` tags of a struct (or a pointer to one).
+// A tag holds a format token optionally followed by comma separated constraints:
+//
+//	Magic  int64  `bp:"!I,magic=0x4C4F4144"`
+//	Opcode int64  `bp:"H,enum=1|2|7"`
+//	Length int64  `bp:"H,min=1,max=512"`
+//	Name   string `bp:"16s,nonzero,utf8,pattern=^[a-z]+$"`
+//	Flags  int64  `bp:"H,since=2,default=1"`
+//
+// pattern takes the rest of the tag, so it must be the last option.
+// Fields without a tag, or tagged "-", are skipped; a blank field tagged with a
+// byte order marker (e.g. `bp:"!"`) sets the order of the fields after it.
+func LayoutOf(v interface{}) (l Layout, err error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, errors.Errorf("LayoutOf expects a struct, got %T", v)
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, ok := sf.Tag.Lookup("bp")
+		if !ok || tag == "-" {
+			continue
+		}
+
+		var f Field
+		if f, err = parseTag(sf.Name, tag); err != nil {
+			return nil, errors.Wrapf(err, "field %s", sf.Name)
+		}
+		if _, marker := orderMarker(f.Format); !marker && sf.PkgPath != "" {
+			return nil, errors.Errorf("field %s is tagged but not exported", sf.Name)
+		}
+		l = append(l, f)
+	}
+	return
+}
+
+func parseTag(name, tag string) (f Field, err error) {
+	var (
+		opts = strings.Split(tag, ",")
+		v    interface{}
+	)
+
+	f = Field{Name: name, Format: opts[0]}
+	if _, marker := orderMarker(f.Format); marker {
+		f.Name = ""
+		return
+	}
+
+	for i := 1; i < len(opts); i++ {
+		key, value := opts[i], ""
+		if eq := strings.Index(key, "="); eq >= 0 {
+			key, value = key[:eq], key[eq+1:]
+		}
+
+		switch key {
+		case "nonzero":
+			f.NonZero = true
+		case "utf8":
+			f.UTF8 = true
+		case "pattern":
+			value = strings.Join(append([]string{value}, opts[i+1:]...), ",")
+			if f.Pattern, err = regexp.Compile(value); err != nil {
+				return
+			}
+			i = len(opts)
+		case "since":
+			if f.Since, err = strconv.Atoi(value); err != nil {
+				return
+			}
+		case "min", "max", "magic", "default":
+			if v, err = parseValue(f.Format, value); err != nil {
+				return
+			}
+			switch key {
+			case "min":
+				f.Min = v
+			case "max":
+				f.Max = v
+			case "magic":
+				f.Magic = v
+			default:
+				f.Default = v
+			}
+		case "enum":
+			for _, s := range strings.Split(value, "|") {
+				if v, err = parseValue(f.Format, s); err != nil {
+					return
+				}
+				f.Enum = append(f.Enum, v)
+			}
+		default:
+			err = errors.New("unknown option '" + key + "'")
+			return
+		}
+	}
+	return
+}
+
+// parseValue parses the text form of a value of the field with the given format token.
+func parseValue(format, s string) (interface{}, error) {
+	f, _ := fieldOrder(format, nil)
+
+	switch f {
+	case "?":
+		return strconv.ParseBool(s)
+	case "h", "H", "i", "I", "l", "L", "q", "Q":
+		return strconv.ParseInt(s, 0, 64)
+	case "f":
+		x, err := strconv.ParseFloat(s, 32)
+		return float32(x), err
+	case "d":
+		return strconv.ParseFloat(s, 64)
+	}
+
+	if strings.Contains(f, ":") {
+		tc, err := parseTimeCode(f)
+		if err != nil {
+			return nil, err
+		}
+		if tc.duration {
+			return time.ParseDuration(s)
+		}
+		return time.Parse(time.RFC3339Nano, s)
+	}
+	if strings.Contains(f, "s") {
+		return s, nil
+	}
+	return nil, errors.New("Unexpected format token: '" + format + "'")
+}
+
+// structValues returns the values of the tagged fields of the struct v.
+func structValues(v interface{}, l Layout) ([]interface{}, error) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return nil, errors.Errorf("expected a struct, got %T", v)
+	}
+
+	var values []interface{}
+	for _, i := range taggedFields(rv.Type()) {
+		values = append(values, toPackValue(rv.Field(i)))
+	}
+	if len(values) != len(l.Fields()) {
+		return nil, errors.Errorf("%T does not match its layout", v)
+	}
+	for i, f := range l.Fields() {
+		// Go float fields are packed at the precision of their format
+		code, _ := fieldOrder(f.Format, nil)
+		switch code {
+		case "f":
+			if x, ok := number.Float64(values[i]); ok {
+				values[i] = float32(x)
+			}
+		case "d":
+			if x, ok := number.Float64(values[i]); ok {
+				values[i] = x
+			}
+		}
+	}
+	return values, nil
+}
+
+// taggedFields returns the indexes of the struct fields that carry a value.
+func taggedFields(t reflect.Type) (indexes []int) {
+	for i := 0; i < t.NumField(); i++ {
+		tag, ok := t.Field(i).Tag.Lookup("bp")
+		if !ok || tag == "-" {
+			continue
+		}
+		if _, marker := orderMarker(strings.Split(tag, ",")[0]); !marker {
+			indexes = append(indexes, i)
+		}
+	}
+	return
+}
+
+// toPackValue converts a struct field to the Go type Pack expects.
+func toPackValue(rv reflect.Value) interface{} {
+	switch v := rv.Interface().(type) {
+	case time.Time, time.Duration:
+		return v
+	}
+
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(rv.Uint())
+	case reflect.Float32:
+		return float32(rv.Float())
+	case reflect.Float64:
+		return rv.Float()
+	}
+	return rv.Interface()
+}
+
+// Pack the tagged fields of the struct v according to LayoutOf(v).
+func (bp *BinaryPack) PackStruct(v interface{}) ([]byte, error) {
+	l, err := LayoutOf(v)
+	if err != nil {
+		return nil, err
+	}
+	values, err := structValues(v, l)
+	if err != nil {
+		return nil, err
+	}
+	return bp.PackLayout(l, values)
+}
+
+// Unpack the byte slice into the tagged fields of the struct pointed to by v according to LayoutOf(v).
+func (bp *BinaryPack) UnPackStruct(msg []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return errors.Errorf("UnPackStruct expects a pointer to a struct, got %T", v)
+	}
+
+	l, err := LayoutOf(v)
+	if err != nil {
+		return err
+	}
+	values, err := bp.UnPackLayout(l, msg)
+	if err != nil {
+		return err
+	}
+
+	rv = rv.Elem()
+	for n, i := range taggedFields(rv.Type()) {
+		field := rv.Field(i)
+		value := reflect.ValueOf(values[n])
+		// reflect converts integers to strings as runes; that is never wanted here
+		if !value.Type().ConvertibleTo(field.Type()) || (field.Kind() == reflect.String && value.Kind() != reflect.String) {
+			return errors.Errorf("cannot store %T in field %s", values[n], rv.Type().Field(i).Name)
+		}
+		field.Set(value.Convert(field.Type()))
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package binarypack
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type testHeader struct {
+	_       struct{}      `bp:"!"`
+	Magic   uint16        `bp:"H,magic=0x1234"`
+	Opcode  int           `bp:"H,enum=1|2|7"`
+	Length  uint32        `bp:"<I,min=1,max=512"`
+	Ratio   float64       `bp:"f,min=0,max=1.5"`
+	Sent    time.Time     `bp:"I:Ts,min=2000-01-01T00:00:00Z"`
+	Timeout time.Duration `bp:"H:Dms,nonzero"`
+	Name    string        `bp:"6s,utf8,pattern=^[a-z]{1,6}$"`
+	Comment string
+}
+
+func TestLayoutOf(t *testing.T) {
+	Convey("TEST LayoutOf", t, func() {
+		l, err := LayoutOf(&testHeader{})
+		So(err, ShouldBeNil)
+		So(l.Format(), ShouldResemble, []string{"!", "H", "H", "<I", "f", "I:Ts", "H:Dms", "6s"})
+		So(l[1].Name, ShouldEqual, "Magic")
+		So(l[1].Magic, ShouldEqual, int64(0x1234))
+		So(l[2].Enum, ShouldResemble, []interface{}{int64(1), int64(2), int64(7)})
+		So(l[4].Max, ShouldEqual, float32(1.5))
+		So(l[5].Min, ShouldResemble, time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC))
+		So(l[7].Pattern.String(), ShouldEqual, "^[a-z]{1,6}$")
+	})
+
+	Convey("TEST LayoutOf invalid", t, func() {
+		_, err := LayoutOf(1)
+		So(err, ShouldNotBeNil)
+		_, err = LayoutOf(struct {
+			A int64 `bp:"H,min=x"`
+		}{})
+		So(err, ShouldNotBeNil)
+		_, err = LayoutOf(struct {
+			A int64 `bp:"H,bogus"`
+		}{})
+		So(err, ShouldNotBeNil)
+		_, err = LayoutOf(struct {
+			a int64 `bp:"H"`
+		}{})
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestBinaryPack_Struct(t *testing.T) {
+	Convey("TEST PackStruct and UnPackStruct", t, func() {
+		in := testHeader{
+			Magic:   0x1234,
+			Opcode:  2,
+			Length:  16,
+			Ratio:   0.5,
+			Sent:    time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+			Timeout: 1500 * time.Millisecond,
+			Name:    "dump",
+			Comment: "not packed",
+		}
+		b, err := new(BinaryPack).PackStruct(&in)
+		So(err, ShouldBeNil)
+		So(b, ShouldResemble, []byte{18, 52, 0, 2, 16, 0, 0, 0, 63, 0, 0, 0, 94, 11, 225, 0, 5, 220, 0, 0, 112, 109, 117, 100})
+
+		var out testHeader
+		So(new(BinaryPack).UnPackStruct(b, &out), ShouldBeNil)
+		in.Comment = ""
+		So(out, ShouldResemble, in)
+	})
+
+	Convey("TEST PackStruct constraint violations", t, func() {
+		in := testHeader{Magic: 0x1234, Opcode: 3, Length: 16, Sent: time.Unix(0, 0), Name: "Dump"}
+		_, err := new(BinaryPack).PackStruct(in)
+		So(err, ShouldHaveSameTypeAs, &ValidationError{})
+		So(err.(*ValidationError).Violations, ShouldHaveLength, 4)
+	})
+
+	Convey("TEST UnPackStruct rejects integers in string fields", t, func() {
+		var out struct {
+			Opcode string `bp:"H"`
+		}
+		So(new(BinaryPack).UnPackStruct([]byte{0, 65}, &out), ShouldNotBeNil)
+		So(out.Opcode, ShouldEqual, "")
+	})
+
+	Convey("TEST UnPackStruct needs a pointer", t, func() {
+		So(new(BinaryPack).UnPackStruct(nil, testHeader{}), ShouldNotBeNil)
+	})
+}
+
+func TestBinaryPack_StructVersions(t *testing.T) {
+	type record struct {
+		Opcode int64  `bp:"H"`
+		Flags  int64  `bp:"H,since=1,default=3"`
+		Name   string `bp:"4s,since=2,default=none"`
+	}
+
+	Convey("TEST UnPackStruct of an older record", t, func() {
+		var out record
+		So(new(BinaryPack).UnPackStruct([]byte{7, 0}, &out), ShouldBeNil)
+		So(out, ShouldResemble, record{Opcode: 7, Flags: 3, Name: "none"})
+
+		So(new(BinaryPack).UnPackStruct([]byte{7, 0, 1, 0}, &out), ShouldBeNil)
+		So(out, ShouldResemble, record{Opcode: 7, Flags: 1, Name: "none"})
+	})
+}
@@ -0,0 +1,109 @@
+#!/usr/bin/env python3
+"""Generates python_struct.json, the conformance corpus of binarypack against Python's struct module.
+
+Every vector holds the Python format, the equivalent binarypack tokens, the packed values,
+the bytes produced by struct.pack and the values returned by struct.unpack. Vectors on which
+binarypack intentionally behaves differently carry the id of the deviation described in
+COMPATIBILITY.md. Vectors with "error" set are rejected by Python.
+
+    python3 testdata/python_struct.py > testdata/python_struct.json
+"""
+
+import json
+import struct
+import sys
+
+INTS = {
+    "h": (-2**15, 2**15 - 1),
+    "H": (0, 2**16 - 1),
+    "i": (-2**31, 2**31 - 1),
+    "I": (0, 2**32 - 1),
+    "l": (-2**31, 2**31 - 1),
+    "L": (0, 2**32 - 1),
+    "q": (-2**63, 2**63 - 1),
+    "Q": (0, 2**64 - 1),
+}
+
+FLOATS = {
+    "f": ["0.0", "-0.0", "1.5", "-2.5", "5.3", "3.4028234663852886e+38", "1.1754943508222875e-38",
+          "1e-45", "inf", "-inf"],
+    "d": ["0.0", "-0.0", "1.5", "-2.5", "5.3", "1.7976931348623157e+308", "2.2250738585072014e-308",
+          "5e-324", "inf", "-inf"],
+}
+
+STRINGS = [("1s", "a"), ("4s", "DUMP"), ("4s", "DU"), ("4s", ""), ("3s", "DUMP"), ("10s", "1234567890")]
+
+# Python format prefix -> binarypack marker token (None: no marker, binarypack defaults to '<')
+ORDERS = [("<", None), ("<", "<"), (">", ">"), ("!", "!")]
+
+
+def text(code, v):
+    if code == "?":
+        return "true" if v else "false"
+    if code in FLOATS:
+        return repr(float(v))
+    if isinstance(v, bytes):
+        return v.decode("ascii")
+    return str(v)
+
+
+def deviation(order, code, value, unpacked):
+    if code.endswith("s"):
+        n = int(code[:-1])
+        s = value[:n]
+        if order in (">", "!") and b"\0" * (n - len(s)) + s[::-1] != unpacked:
+            return "D7"
+        if unpacked != unpacked.rstrip(b"\0"):
+            return "D6"
+    if code in INTS and INTS[code][0] == 0 and unpacked > INTS[code.lower()][1]:
+        return "D3"
+    return None
+
+
+def vector(order, marker, codes, values):
+    fmt = order + "".join(codes)
+    tokens = ([marker] if marker else []) + list(codes)
+    v = {"format": fmt, "tokens": tokens, "values": [text(c, x) for c, x in zip(codes, values)]}
+    try:
+        packed = struct.pack(fmt, *values)
+    except struct.error:
+        v["error"] = True
+        v["deviation"] = "D4"
+        return v
+    unpacked = struct.unpack(fmt, packed)
+    v["bytes"] = packed.hex()
+    v["unpacked"] = [text(c, x) for c, x in zip(codes, unpacked)]
+    devs = [d for d in (deviation(order, c, x, u) for c, x, u in zip(codes, values, unpacked)) if d]
+    if devs:
+        v["deviation"] = devs[0]
+    return v
+
+
+def main():
+    vectors = []
+    for order, marker in ORDERS:
+        for b in (True, False):
+            vectors.append(vector(order, marker, ["?"], [b]))
+        for code, (lo, hi) in INTS.items():
+            for x in sorted({lo, lo + 1, -1 if lo < 0 else 0, 0, 1, 2300, hi - 1, hi}):
+                vectors.append(vector(order, marker, [code], [x]))
+        for code, xs in FLOATS.items():
+            for x in xs:
+                vectors.append(vector(order, marker, [code], [float(x)]))
+        for code, s in STRINGS:
+            vectors.append(vector(order, marker, [code], [s.encode("ascii")]))
+        vectors.append(vector(order, marker, ["I", "I", "I", "4s"], [1, 2, 4, b"DUMP"]))
+        vectors.append(vector(order, marker, ["i", "h", "d", "5s"], [1, 2, 4.8, b"DUMP"]))
+        vectors.append(vector(order, marker, ["?", "h", "H", "i", "I", "l", "L", "q", "Q", "f", "d"],
+                              [True, -2, 2, -4, 4, -8, 8, -16, 16, 0.5, 0.25]))
+        # Out of range values are rejected by Python and truncated by binarypack
+        for code, (lo, hi) in INTS.items():
+            if code not in ("q", "Q"):
+                vectors.append(vector(order, marker, [code], [lo - 1]))
+                vectors.append(vector(order, marker, [code], [hi + 1]))
+
+    sys.stdout.write("[\n" + ",\n".join(json.dumps(v) for v in vectors) + "\n]\n")
+
+
+if __name__ == "__main__":
+    main()
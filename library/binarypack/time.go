@@ -0,0 +1,194 @@
+package binarypack
+
+import (
+	"encoding/binary"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// timeUnits are the resolutions accepted by time fields, longest suffix first.
+var timeUnits = []struct {
+	name string
+	unit time.Duration
+}{
+	{"100ns", 100 * time.Nanosecond},
+	{"ns", time.Nanosecond},
+	{"us", time.Microsecond},
+	{"ms", time.Millisecond},
+	{"s", time.Second},
+	{"m", time.Minute},
+	{"h", time.Hour},
+}
+
+// timeEpochs are the named epochs accepted by time fields.
+var timeEpochs = map[string]time.Time{
+	"unix":    time.Unix(0, 0).UTC(),
+	"windows": time.Date(1601, 1, 1, 0, 0, 0, 0, time.UTC),
+	"ntp":     time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC),
+	"gps":     time.Date(1980, 1, 6, 0, 0, 0, 0, time.UTC),
+}
+
+// Unix times a time.Time can hold: it counts seconds from year 1 in an int64.
+var (
+	minUnix = big.NewInt(math.MinInt64)
+	maxUnix = big.NewInt(math.MaxInt64 - 62135596800)
+)
+
+// timeCode is a parsed time field such as "q:Tms" or "Q:T100ns@windows".
+type timeCode struct {
+	size     int
+	unsigned bool
+	duration bool
+	unit     time.Duration
+	epoch    time.Time
+}
+
+func parseTimeCode(f string) (tc timeCode, err error) {
+	parts := strings.SplitN(f, ":", 2)
+	switch parts[0] {
+	case "h", "H":
+		tc.size = 2
+	case "i", "I", "l", "L":
+		tc.size = 4
+	case "q", "Q":
+		tc.size = 8
+	default:
+		err = errors.New("time fields need an integer code, got '" + parts[0] + "'")
+		return
+	}
+	tc.unsigned = strings.ToUpper(parts[0]) == parts[0]
+
+	spec := parts[1]
+	switch {
+	case strings.HasPrefix(spec, "T"):
+		tc.epoch = timeEpochs["unix"]
+		if at := strings.Index(spec, "@"); at >= 0 {
+			if tc.epoch, err = parseEpoch(spec[at+1:]); err != nil {
+				return
+			}
+			spec = spec[:at]
+		}
+	case strings.HasPrefix(spec, "D"):
+		tc.duration = true
+	default:
+		err = errors.New("time spec must start with T or D, got '" + spec + "'")
+		return
+	}
+
+	spec = spec[1:]
+	if spec == "" {
+		tc.unit = time.Second
+		return
+	}
+	for _, u := range timeUnits {
+		if spec == u.name {
+			tc.unit = u.unit
+			return
+		}
+	}
+	err = errors.New("unknown time unit '" + spec + "'")
+	return
+}
+
+func parseEpoch(s string) (time.Time, error) {
+	if epoch, ok := timeEpochs[s]; ok {
+		return epoch, nil
+	}
+	epoch, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return time.Time{}, errors.New("unknown epoch '" + s + "'")
+	}
+	return epoch, nil
+}
+
+// limits returns the smallest and largest integer the field can hold.
+func (tc timeCode) limits() (min, max *big.Int) {
+	bits := uint(tc.size * 8)
+	if tc.unsigned {
+		max = new(big.Int).Lsh(big.NewInt(1), bits)
+		return big.NewInt(0), max.Sub(max, big.NewInt(1))
+	}
+	max = new(big.Int).Lsh(big.NewInt(1), bits-1)
+	min = new(big.Int).Neg(max)
+	return min, max.Sub(max, big.NewInt(1))
+}
+
+// ticks converts v to the number of units the field stores.
+func (tc timeCode) ticks(v interface{}) (*big.Int, error) {
+	unit := big.NewInt(int64(tc.unit))
+
+	if tc.duration {
+		d, ok := v.(time.Duration)
+		if !ok {
+			return nil, errors.New("type of passed value doesn't match to expected time.Duration")
+		}
+		// Div floors as for times, so negative durations round down too
+		return new(big.Int).Div(big.NewInt(int64(d)), unit), nil
+	}
+
+	t, ok := v.(time.Time)
+	if !ok {
+		return nil, errors.New("type of passed value doesn't match to expected time.Time")
+	}
+	ns := big.NewInt(t.Unix() - tc.epoch.Unix())
+	ns.Mul(ns, big.NewInt(int64(time.Second)))
+	ns.Add(ns, big.NewInt(int64(t.Nanosecond()-tc.epoch.Nanosecond())))
+	// Div rounds towards negative infinity, so times before the epoch are truncated consistently
+	return ns.Div(ns, unit), nil
+}
+
+func (tc timeCode) pack(v interface{}, order binary.ByteOrder) ([]byte, error) {
+	n, err := tc.ticks(v)
+	if err != nil {
+		return nil, err
+	}
+
+	min, max := tc.limits()
+	if n.Cmp(min) < 0 || n.Cmp(max) > 0 {
+		return nil, errors.Errorf("%v is out of range: %s units of %v do not fit in %d bytes", v, n, tc.unit, tc.size)
+	}
+
+	if tc.unsigned {
+		return int64ToBytes(int64(n.Uint64()), tc.size, order), nil
+	}
+	return int64ToBytes(n.Int64(), tc.size, order), nil
+}
+
+func (tc timeCode) unpack(b []byte, order binary.ByteOrder) (interface{}, error) {
+	var n *big.Int
+
+	if tc.unsigned {
+		var u uint64
+		switch tc.size {
+		case 2:
+			u = uint64(order.Uint16(b))
+		case 4:
+			u = uint64(order.Uint32(b))
+		default:
+			u = order.Uint64(b)
+		}
+		n = new(big.Int).SetUint64(u)
+	} else {
+		n = big.NewInt(bytesToInt64(b, order))
+	}
+	n.Mul(n, big.NewInt(int64(tc.unit)))
+
+	if tc.duration {
+		if !n.IsInt64() {
+			return nil, errors.Errorf("%s ns overflows time.Duration", n)
+		}
+		return time.Duration(n.Int64()), nil
+	}
+
+	n.Add(n, big.NewInt(int64(tc.epoch.Nanosecond())))
+	sec, nsec := new(big.Int).DivMod(n, big.NewInt(int64(time.Second)), new(big.Int))
+	sec.Add(sec, big.NewInt(tc.epoch.Unix()))
+	if sec.Cmp(minUnix) < 0 || sec.Cmp(maxUnix) > 0 {
+		return nil, errors.Errorf("%s s since the Unix epoch overflows time.Time", sec)
+	}
+	return time.Unix(sec.Int64(), nsec.Int64()).UTC(), nil
+}
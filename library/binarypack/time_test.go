@@ -0,0 +1,100 @@
+package binarypack
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestBinaryPack_Time(t *testing.T) {
+	type Case struct {
+		f    []string
+		a    []interface{}
+		want []byte
+	}
+	newYear := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	cases := []Case{
+		{[]string{"I:Ts"}, []interface{}{newYear}, []byte{0, 225, 11, 94}},
+		{[]string{"I:T"}, []interface{}{newYear}, []byte{0, 225, 11, 94}},
+		{[]string{">I:Ts"}, []interface{}{newYear}, []byte{94, 11, 225, 0}},
+		{[]string{"q:Tms"}, []interface{}{newYear.Add(123 * time.Millisecond)},
+			[]byte{123, 232, 102, 94, 111, 1, 0, 0}},
+		{[]string{"Q:T100ns@windows"}, []interface{}{newYear}, []byte{0, 0, 5, 105, 54, 192, 213, 1}},
+		{[]string{"I:Ts@2000-01-01"}, []interface{}{newYear}, []byte{128, 157, 158, 37}},
+		{[]string{"i:Ts"}, []interface{}{time.Date(1960, 1, 1, 0, 0, 0, 0, time.UTC)}, []byte{128, 8, 48, 237}},
+		{[]string{"i:Dms"}, []interface{}{1500 * time.Millisecond}, []byte{220, 5, 0, 0}},
+		{[]string{"H", "i:Dms", "I:Ts"}, []interface{}{int64(1), 1500 * time.Millisecond, newYear},
+			[]byte{1, 0, 220, 5, 0, 0, 0, 225, 11, 94}},
+	}
+
+	Convey("TEST Pack and UnPack time fields", t, func() {
+		for _, c := range cases {
+			got, err := new(BinaryPack).Pack(c.f, c.a)
+			So(err, ShouldBeNil)
+			So(got, ShouldResemble, c.want)
+
+			size, err := new(BinaryPack).CalcSize(c.f)
+			So(err, ShouldBeNil)
+			So(size, ShouldEqual, len(c.want))
+
+			values, err := new(BinaryPack).UnPack(c.f, c.want)
+			So(err, ShouldBeNil)
+			So(values, ShouldResemble, c.a)
+		}
+	})
+
+	Convey("TEST time fields are truncated to their unit", t, func() {
+		got, err := new(BinaryPack).Pack([]string{"I:Ts"}, []interface{}{newYear.Add(999 * time.Millisecond)})
+		So(err, ShouldBeNil)
+		So(got, ShouldResemble, []byte{0, 225, 11, 94})
+
+		// Towards negative infinity, for durations as for times before the epoch
+		got, err = new(BinaryPack).Pack([]string{"i:Ds"}, []interface{}{-500 * time.Millisecond})
+		So(err, ShouldBeNil)
+		So(got, ShouldResemble, []byte{255, 255, 255, 255})
+		got, err = new(BinaryPack).Pack([]string{"i:Ts"}, []interface{}{time.Unix(0, -500*int64(time.Millisecond))})
+		So(err, ShouldBeNil)
+		So(got, ShouldResemble, []byte{255, 255, 255, 255})
+	})
+
+	invalids := []Case{
+		// Out of range for the integer code
+		{[]string{"H:Ts"}, []interface{}{newYear}, nil},
+		{[]string{"I:Ts"}, []interface{}{time.Date(1960, 1, 1, 0, 0, 0, 0, time.UTC)}, nil},
+		{[]string{"h:Dms"}, []interface{}{time.Minute}, nil},
+		// Wrong types
+		{[]string{"q:Tms"}, []interface{}{int64(1)}, nil},
+		{[]string{"q:Dms"}, []interface{}{newYear}, nil},
+		// Wrong specs
+		{[]string{"f:Ts"}, []interface{}{newYear}, nil},
+		{[]string{"q:Xs"}, []interface{}{newYear}, nil},
+		{[]string{"q:Tfortnight"}, []interface{}{newYear}, nil},
+		{[]string{"q:Ts@mars"}, []interface{}{newYear}, nil},
+	}
+
+	Convey("TEST Pack time fields invalid", t, func() {
+		for _, c := range invalids {
+			_, err := new(BinaryPack).Pack(c.f, c.a)
+			So(err, ShouldNotBeNil)
+		}
+
+		_, err := new(BinaryPack).CalcSize([]string{"q:Tfortnight"})
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("TEST UnPack time fields overflowing time.Duration", t, func() {
+		_, err := new(BinaryPack).UnPack([]string{"Q:Dh"}, []byte{255, 255, 255, 255, 255, 255, 255, 255})
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("TEST UnPack time fields overflowing time.Time", t, func() {
+		// The largest int64 of seconds, from an epoch after 1970 or not
+		for _, f := range []string{"<q:Ts", "<q:Ts@windows"} {
+			_, err := new(BinaryPack).UnPack([]string{f}, []byte{255, 255, 255, 255, 255, 255, 255, 127})
+			So(err, ShouldNotBeNil)
+		}
+		_, err := new(BinaryPack).UnPack([]string{"<Q:Th"}, []byte{255, 255, 255, 255, 255, 255, 255, 255})
+		So(err, ShouldNotBeNil)
+	})
+}
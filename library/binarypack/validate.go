@@ -0,0 +1,221 @@
+package binarypack
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/eyotang/load/library/binarypack/internal/number"
+)
+
+// Violation is a single failed constraint.
+type Violation struct {
+	Index  int    // position of the value in the values of the layout
+	Name   string // name of the field, if any
+	Offset int    // byte offset of the field in the packed record
+	Rule   string // the failed constraint, e.g. "max=10"
+	Value  interface{}
+}
+
+func (v Violation) String() string {
+	field := fmt.Sprintf("field %d", v.Index)
+	if v.Name != "" {
+		field += " (" + v.Name + ")"
+	}
+	return fmt.Sprintf("%s at offset %d: %#v violates %s", field, v.Offset, v.Value, v.Rule)
+}
+
+// ValidationError aggregates every constraint violation found in one record.
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		msgs = append(msgs, v.String())
+	}
+	return fmt.Sprintf("%d constraint violation(s): %s", len(e.Violations), strings.Join(msgs, "; "))
+}
+
+// Validate checks values against the constraints of the layout and returns
+// a *ValidationError listing every violation, or nil.
+func (l Layout) Validate(values []interface{}) error {
+	var violations []Violation
+
+	offsets, _, err := l.spans()
+	if err != nil {
+		return err
+	}
+
+	for i, f := range l.Fields() {
+		if i >= len(values) {
+			break
+		}
+		for _, rule := range f.check(values[i]) {
+			violations = append(violations, Violation{
+				Index:  i,
+				Name:   f.Name,
+				Offset: offsets[i],
+				Rule:   rule,
+				Value:  values[i],
+			})
+		}
+	}
+
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+	return nil
+}
+
+// check returns the constraints of the field that v violates.
+func (f Field) check(v interface{}) (failed []string) {
+	if f.Magic != nil && !equal(v, f.Magic) {
+		failed = append(failed, fmt.Sprintf("magic=%v", f.Magic))
+	}
+	if f.NonZero && (v == nil || reflect.ValueOf(v).IsZero()) {
+		failed = append(failed, "nonzero")
+	}
+	if f.Min != nil {
+		if c, ok := compare(v, f.Min); !ok || c < 0 {
+			failed = append(failed, fmt.Sprintf("min=%v", f.Min))
+		}
+	}
+	if f.Max != nil {
+		if c, ok := compare(v, f.Max); !ok || c > 0 {
+			failed = append(failed, fmt.Sprintf("max=%v", f.Max))
+		}
+	}
+	if len(f.Enum) > 0 {
+		found := false
+		for _, e := range f.Enum {
+			if equal(v, e) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			names := make([]string, 0, len(f.Enum))
+			for _, e := range f.Enum {
+				names = append(names, fmt.Sprint(e))
+			}
+			failed = append(failed, "enum="+strings.Join(names, "|"))
+		}
+	}
+	if f.UTF8 {
+		if s, ok := v.(string); !ok || !utf8.ValidString(s) {
+			failed = append(failed, "utf8")
+		}
+	}
+	if f.Pattern != nil {
+		if s, ok := v.(string); !ok || !f.Pattern.MatchString(s) {
+			failed = append(failed, "pattern="+f.Pattern.String())
+		}
+	}
+	return
+}
+
+func equal(a, b interface{}) bool {
+	c, ok := compare(a, b)
+	return ok && c == 0
+}
+
+// compare orders two values of compatible types. Integers of any size compare
+// exactly with each other and numbers of mixed kinds compare as float64.
+func compare(a, b interface{}) (int, bool) {
+	switch x := a.(type) {
+	case time.Time:
+		y, ok := b.(time.Time)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case x.Before(y):
+			return -1, true
+		case x.After(y):
+			return 1, true
+		}
+		return 0, true
+	case time.Duration:
+		y, ok := b.(time.Duration)
+		if !ok {
+			return 0, false
+		}
+		return compareInt64(int64(x), int64(y)), true
+	case string:
+		y, ok := b.(string)
+		if !ok {
+			return 0, false
+		}
+		return strings.Compare(x, y), true
+	case bool:
+		y, ok := b.(bool)
+		if !ok || x != y {
+			return 0, false
+		}
+		return 0, true
+	}
+
+	if c, ok := compareInteger(a, b); ok {
+		return c, true
+	}
+	x, ok := number.Float64(a)
+	if !ok {
+		return 0, false
+	}
+	y, ok := number.Float64(b)
+	if !ok {
+		return 0, false
+	}
+	switch {
+	case x < y:
+		return -1, true
+	case x > y:
+		return 1, true
+	case x == y:
+		return 0, true
+	}
+	return 0, false
+}
+
+// compareInteger orders two integers of any Go type, including uint64 values
+// above MaxInt64.
+func compareInteger(a, b interface{}) (int, bool) {
+	x, xok := number.Int64(a)
+	y, yok := number.Int64(b)
+	if xok && yok {
+		return compareInt64(x, y), true
+	}
+
+	ux, uxok := number.Uint64(a)
+	uy, uyok := number.Uint64(b)
+	switch {
+	case uxok && uyok:
+		switch {
+		case ux < uy:
+			return -1, true
+		case ux > uy:
+			return 1, true
+		}
+		return 0, true
+	case uxok && yok:
+		// a is above MaxInt64
+		return 1, true
+	case xok && uyok:
+		return -1, true
+	}
+	return 0, false
+}
+
+func compareInt64(x, y int64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,64 @@
+package binarypack
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCompare(t *testing.T) {
+	type Case struct {
+		a, b interface{}
+		want int
+		ok   bool
+	}
+	day := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	cases := []Case{
+		{int64(1), 2, -1, true},
+		{int64(1<<62 + 1), int64(1 << 62), 1, true},
+		{uint16(7), int64(7), 0, true},
+		{float32(1.5), 1, 1, true},
+		{0.5, float32(0.5), 0, true},
+		{"abc", "abd", -1, true},
+		{true, true, 0, true},
+		{true, false, 0, false},
+		{day, day.Add(time.Second), -1, true},
+		{time.Second, time.Millisecond, 1, true},
+		{time.Second, int64(1), 0, false},
+		{"1", 1, 0, false},
+		{nil, 1, 0, false},
+		{uint64(1 << 63), int64(math.MaxInt64), 1, true},
+		{int64(-1), uint64(math.MaxUint64), -1, true},
+		{uint64(1<<63 + 1), uint64(1 << 63), 1, true},
+		{uint64(math.MaxUint64), 1.5, 1, true},
+	}
+
+	Convey("TEST compare", t, func() {
+		for _, c := range cases {
+			got, ok := compare(c.a, c.b)
+			So(ok, ShouldEqual, c.ok)
+			So(got, ShouldEqual, c.want)
+		}
+	})
+}
+
+func TestField_check(t *testing.T) {
+	Convey("TEST Field check of time and duration bounds", t, func() {
+		day := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		f := Field{Format: "I:Ts", Min: day, Max: day.Add(time.Hour)}
+		So(f.check(day), ShouldBeEmpty)
+		So(f.check(day.Add(-time.Second)), ShouldResemble, []string{"min=" + day.String()})
+
+		f = Field{Format: "i:Dms", NonZero: true, Max: time.Second}
+		So(f.check(time.Duration(0)), ShouldResemble, []string{"nonzero"})
+		So(f.check(2*time.Second), ShouldResemble, []string{"max=1s"})
+	})
+
+	Convey("TEST Field check of uint64 above MaxInt64", t, func() {
+		f := Field{Format: "Q", Min: int64(0), Max: int64(100)}
+		So(f.check(uint64(1<<63)), ShouldResemble, []string{"max=100"})
+		So(f.check(uint64(100)), ShouldBeEmpty)
+	})
+}
@@ -0,0 +1,445 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// Modes of crypting / cyphering
+	ECB = 0
+	CBC = 1
+	CFB = 2
+	OFB = 3
+	CTR = 4
+	GCM = 5
+
+	// RANDOM_IV is or-ed into a mode to draw a fresh IV for every message
+	// from crypto/rand and prepend it to the ciphertext, see SetRandomIV
+	RANDOM_IV = 0x80
+
+	// Modes of padding
+	PAD_NORMAL   = 1
+	PAD_PKCS5    = 2
+	PAD_ISO10126 = 3
+	PAD_ANSIX923 = 4
+	PAD_ISO7816  = 5
+	PAD_NONE     = 6
+)
+
+// Cipher is the interface shared by BlockCipher and the ciphers built on it.
+// Encrypt and Decrypt return nil where Seal and Open fail; use Open to tell
+// ErrAuthentication from other failures.
+type Cipher interface {
+	Encrypt(plaintext []byte) []byte
+	Decrypt(ciphertext []byte) []byte
+	Seal(plaintext, additionalData []byte) ([]byte, error)
+	Open(ciphertext, additionalData []byte) ([]byte, error)
+}
+
+// BlockCipher encrypts and decrypts with any cipher.Block (DES, 3DES, AES, ...)
+// in one of the modes and paddings above. CFB, OFB, CTR and GCM do not pad.
+// Every call starts over from the IV, so a BlockCipher may be shared between
+// goroutines; use a Session to chain messages.
+type BlockCipher struct {
+	mode uint8
+	pad  Padding
+	iv   []byte
+	b    cipher.Block
+	aead cipher.AEAD
+	rand io.Reader
+
+	fixedNonce bool
+}
+
+// ErrAuthentication is returned by Open when GCM or Envelope ciphertext or
+// its additional data has been tampered with.
+var ErrAuthentication = errors.New("message authentication failed")
+
+var (
+	errNoIV       = errors.New("no IV: sessions chain from the IV passed to the constructor")
+	errFixedNonce = errors.New("fixed GCM nonce: call AllowFixedNonce to seal with it")
+)
+
+// NewBlockCipher wraps b. iv is one block long for CBC, CFB, OFB and CTR and
+// is the nonce for GCM; ECB ignores it. CBC, CFB, OFB and CTR need an iv
+// unless mode has RANDOM_IV set, e.g. CBC|RANDOM_IV; a nil iv in GCM makes
+// every Seal draw a fresh 12-byte nonce from crypto/rand. A fixed iv repeats
+// the CFB, OFB and CTR keystream and the GCM nonce in every message, which
+// leaks the XOR of the plaintexts; GCM only seals with one after
+// AllowFixedNonce.
+func NewBlockCipher(b cipher.Block, mode uint8, iv []byte, padmode uint8) (c *BlockCipher, err error) {
+	var (
+		aead    cipher.AEAD
+		pad, ok = paddings[padmode]
+		random  = mode&RANDOM_IV != 0
+	)
+
+	mode &^= RANDOM_IV
+
+	// Unknown padding modes, 0 included, zero pad as PAD_NORMAL does
+	if !ok {
+		pad = ZeroPad
+	}
+
+	switch mode {
+	case ECB:
+	case CBC, CFB, OFB, CTR:
+		if (iv != nil || !random) && len(iv) != b.BlockSize() {
+			err = errors.Errorf("IV must be %d bytes, got %d", b.BlockSize(), len(iv))
+			return
+		}
+	case GCM:
+		if iv == nil {
+			aead, err = cipher.NewGCM(b)
+		} else {
+			aead, err = cipher.NewGCMWithNonceSize(b, len(iv))
+		}
+		if err != nil {
+			err = errors.Wrapf(err, "cipher.NewGCM failed")
+			return
+		}
+	default:
+		err = errors.Errorf("unsupported mode %d", mode)
+		return
+	}
+
+	c = &BlockCipher{
+		b:    b,
+		iv:   clone(iv),
+		aead: aead,
+		mode: mode,
+		pad:  pad,
+	}
+	if random || mode == GCM && iv == nil {
+		c.rand = rand.Reader
+	}
+
+	return
+}
+
+// NewAes is NewDes for AES; the key is 16, 24 or 32 bytes long for AES-128, AES-192 or AES-256.
+func NewAes(key []byte, mode uint8, iv []byte, padmode uint8) (c *BlockCipher, err error) {
+	var b cipher.Block
+
+	if b, err = aes.NewCipher(key); err != nil {
+		err = errors.Wrapf(err, "aes.NewCipher failed")
+		return
+	}
+
+	return NewBlockCipher(b, mode, iv, padmode)
+}
+
+// SetPadding replaces the padding given by padmode, e.g. with PKCS7 or a
+// custom Padding. Only ECB and CBC pad.
+func (c *BlockCipher) SetPadding(p Padding) {
+	c.pad = p
+}
+
+// SetRandomIV makes every Seal draw a fresh IV (the nonce in GCM) from r and
+// prepend it to the ciphertext, and every Open read it back from there, as
+// RANDOM_IV does with crypto/rand. A nil r means crypto/rand; other readers
+// must be safe for concurrent use if the BlockCipher is shared. ECB has no IV
+// and is not affected. Sessions keep chaining from the IV given to the
+// constructor.
+func (c *BlockCipher) SetRandomIV(r io.Reader) {
+	if r == nil {
+		r = rand.Reader
+	}
+	c.rand = r
+}
+
+// AllowFixedNonce lets GCM seal with the nonce given to the constructor, for
+// test vectors and peers that manage nonces themselves. Sealing two messages
+// with the same key and nonce breaks both confidentiality and authenticity.
+func (c *BlockCipher) AllowFixedNonce() {
+	c.fixedNonce = true
+}
+
+// ivSize is the length of the IV or nonce, 0 in ECB.
+func (c *BlockCipher) ivSize() int {
+	switch c.mode {
+	case ECB:
+		return 0
+	case GCM:
+		return c.aead.NonceSize()
+	}
+	return c.b.BlockSize()
+}
+
+// blockMode returns a fresh ECB or CBC mode, nil for the stream modes.
+func (c *BlockCipher) blockMode(decrypt bool, iv []byte) cipher.BlockMode {
+	switch c.mode {
+	case ECB:
+		if decrypt {
+			return ecbDecrypter{c.b}
+		}
+		return ecbEncrypter{c.b}
+	case CBC:
+		if decrypt {
+			return cipher.NewCBCDecrypter(c.b, iv)
+		}
+		return cipher.NewCBCEncrypter(c.b, iv)
+	}
+	return nil
+}
+
+// stream returns a fresh CFB, OFB or CTR keystream, nil for the block modes.
+func (c *BlockCipher) stream(decrypt bool, iv []byte) cipher.Stream {
+	switch c.mode {
+	case CFB:
+		if decrypt {
+			return cipher.NewCFBDecrypter(c.b, iv)
+		}
+		return cipher.NewCFBEncrypter(c.b, iv)
+	case OFB:
+		return cipher.NewOFB(c.b, iv)
+	case CTR:
+		return cipher.NewCTR(c.b, iv)
+	}
+	return nil
+}
+
+// Encrypt is Seal without additional data.
+func (c *BlockCipher) Encrypt(plaintext []byte) (ciphertext []byte) {
+	ciphertext, _ = c.Seal(plaintext, nil)
+	return
+}
+
+// Decrypt is Open without additional data; it returns nil where Open fails,
+// including on ErrAuthentication.
+func (c *BlockCipher) Decrypt(ciphertext []byte) (plaintext []byte) {
+	plaintext, _ = c.Open(ciphertext, nil)
+	return
+}
+
+// Seal encrypts plaintext. It fails if the padding cannot be applied, e.g.
+// NoPadding with a partial block. In GCM mode the result carries the authentication
+// tag over plaintext and additionalData; the other modes ignore additionalData.
+// A random IV or nonce goes in front of the ciphertext.
+func (c *BlockCipher) Seal(plaintext, additionalData []byte) (ciphertext []byte, err error) {
+	var (
+		iv     []byte
+		random bool
+	)
+
+	if iv, random, err = c.sealIV(); err != nil {
+		return
+	}
+
+	if c.mode == GCM {
+		ciphertext = c.aead.Seal(nil, iv, plaintext, additionalData)
+	} else if ciphertext, err = c.seal(c.blockMode(false, iv), c.stream(false, iv), plaintext); err != nil {
+		return
+	}
+
+	if random {
+		ciphertext = append(iv, ciphertext...)
+	}
+
+	return
+}
+
+// sealIV returns the IV for a new message and whether it is random and so
+// goes in front of the ciphertext.
+func (c *BlockCipher) sealIV() (iv []byte, random bool, err error) {
+	n := c.ivSize()
+
+	if c.rand != nil && n > 0 {
+		iv = make([]byte, n)
+		if _, err = io.ReadFull(c.rand, iv); err != nil {
+			err = errors.Wrapf(err, "reading random IV failed")
+			return
+		}
+		return iv, true, nil
+	}
+
+	if c.iv == nil && n > 0 {
+		err = errNoIV
+	} else if c.mode == GCM && !c.fixedNonce {
+		err = errFixedNonce
+	}
+	return c.iv, false, err
+}
+
+// Open decrypts ciphertext. It fails if ECB or CBC ciphertext is not a whole
+// number of blocks or its padding is malformed, and in GCM mode with
+// ErrAuthentication if ciphertext or additionalData has been tampered with.
+func (c *BlockCipher) Open(ciphertext, additionalData []byte) (plaintext []byte, err error) {
+	var (
+		n  = c.ivSize()
+		iv = c.iv
+	)
+
+	if c.rand != nil && n > 0 {
+		if len(ciphertext) < n {
+			err = errors.Errorf("ciphertext is shorter than the %d-byte IV", n)
+			return
+		}
+		iv, ciphertext = ciphertext[:n], ciphertext[n:]
+	} else if iv == nil && n > 0 {
+		err = errNoIV
+		return
+	}
+
+	if c.mode == GCM {
+		if plaintext, err = c.aead.Open(nil, iv, ciphertext, additionalData); err != nil {
+			plaintext, err = nil, ErrAuthentication
+		}
+		return
+	}
+
+	return c.open(c.blockMode(true, iv), c.stream(true, iv), ciphertext)
+}
+
+func (c *BlockCipher) seal(bm cipher.BlockMode, s cipher.Stream, plaintext []byte) (ciphertext []byte, err error) {
+	if s != nil {
+		ciphertext = make([]byte, len(plaintext))
+		s.XORKeyStream(ciphertext, plaintext)
+		return
+	}
+
+	if plaintext, err = c.pad.Pad(plaintext, bm.BlockSize()); err != nil {
+		return
+	}
+
+	ciphertext = make([]byte, len(plaintext))
+	bm.CryptBlocks(ciphertext, plaintext)
+
+	return
+}
+
+func (c *BlockCipher) open(bm cipher.BlockMode, s cipher.Stream, ciphertext []byte) (plaintext []byte, err error) {
+	if s != nil {
+		plaintext = make([]byte, len(ciphertext))
+		s.XORKeyStream(plaintext, ciphertext)
+		return
+	}
+
+	if len(ciphertext)%bm.BlockSize() != 0 {
+		err = errors.Errorf("ciphertext length %d is not a multiple of the block size %d", len(ciphertext), bm.BlockSize())
+		return
+	}
+
+	plaintext = make([]byte, len(ciphertext))
+	bm.CryptBlocks(plaintext, ciphertext)
+
+	if plaintext, err = c.pad.Unpad(plaintext, bm.BlockSize()); err != nil {
+		plaintext = nil
+	}
+
+	return
+}
+
+// Session is a stateful view of a BlockCipher for protocols that chain across
+// messages: in CBC each message continues from the last ciphertext block of
+// the previous one, in CFB, OFB and CTR the keystream carries on. ECB and GCM
+// have no chaining state and behave as the BlockCipher does.
+// Encryption and decryption chain separately. A Session is not safe for
+// concurrent use.
+type Session struct {
+	c   *BlockCipher
+	ebm cipher.BlockMode
+	es  cipher.Stream
+	ds  cipher.Stream
+	div []byte // IV of the next message to open in CBC
+}
+
+// NewSession starts a Session at the IV given to the constructor. Without
+// one, which only RANDOM_IV allows, sessions in modes other than ECB and GCM
+// fail to Seal and Open.
+func (c *BlockCipher) NewSession() *Session {
+	if c.iv == nil && c.mode != ECB {
+		return &Session{c: c}
+	}
+	return &Session{
+		c:   c,
+		ebm: c.blockMode(false, c.iv),
+		es:  c.stream(false, c.iv),
+		ds:  c.stream(true, c.iv),
+		div: clone(c.iv),
+	}
+}
+
+func (s *Session) Encrypt(plaintext []byte) (ciphertext []byte) {
+	ciphertext, _ = s.Seal(plaintext, nil)
+	return
+}
+
+func (s *Session) Decrypt(ciphertext []byte) (plaintext []byte) {
+	plaintext, _ = s.Open(ciphertext, nil)
+	return
+}
+
+// Seal is BlockCipher.Seal continuing the session's chain.
+func (s *Session) Seal(plaintext, additionalData []byte) (ciphertext []byte, err error) {
+	if s.c.mode == GCM {
+		return s.c.Seal(plaintext, additionalData)
+	}
+	if s.ebm == nil && s.es == nil {
+		return nil, errNoIV
+	}
+	return s.c.seal(s.ebm, s.es, plaintext)
+}
+
+// Open is BlockCipher.Open continuing the session's chain. In ECB and CBC a
+// failed Open leaves the chain where it was, so the next message still opens;
+// the stream modes cannot detect a bad message and always move on.
+func (s *Session) Open(ciphertext, additionalData []byte) (plaintext []byte, err error) {
+	switch s.c.mode {
+	case GCM:
+		return s.c.Open(ciphertext, additionalData)
+	case ECB:
+		return s.c.open(s.c.blockMode(true, nil), nil, ciphertext)
+	case CBC:
+		if s.div == nil {
+			return nil, errNoIV
+		}
+		// Decrypt from a copy of the chain and move it on only on success
+		if plaintext, err = s.c.open(s.c.blockMode(true, s.div), nil, ciphertext); err == nil && len(ciphertext) > 0 {
+			s.div = clone(ciphertext[len(ciphertext)-len(s.div):])
+		}
+		return
+	}
+	if s.ds == nil {
+		return nil, errNoIV
+	}
+	return s.c.open(nil, s.ds, ciphertext)
+}
+
+type ecbEncrypter struct{ b cipher.Block }
+
+func (e ecbEncrypter) BlockSize() int { return e.b.BlockSize() }
+
+func (e ecbEncrypter) CryptBlocks(dst, src []byte) {
+	bs := e.b.BlockSize()
+	for len(src) > 0 {
+		e.b.Encrypt(dst, src[:bs])
+		src = src[bs:]
+		dst = dst[bs:]
+	}
+}
+
+type ecbDecrypter struct{ b cipher.Block }
+
+func (d ecbDecrypter) BlockSize() int { return d.b.BlockSize() }
+
+func (d ecbDecrypter) CryptBlocks(dst, src []byte) {
+	bs := d.b.BlockSize()
+	for len(src) > 0 {
+		d.b.Decrypt(dst, src[:bs])
+		src = src[bs:]
+		dst = dst[bs:]
+	}
+}
+
+func clone(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return append([]byte{}, b...)
+}
@@ -0,0 +1,372 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/des"
+	"encoding/hex"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/pkg/errors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAes(t *testing.T) {
+	type params struct {
+		key          string
+		mode         uint8
+		iv           string
+		plaintext    string
+		expectCipher string
+	}
+
+	var (
+		pars = []params{
+			// FIPS-197 appendix C
+			{
+				key:          "000102030405060708090a0b0c0d0e0f",
+				mode:         ECB,
+				plaintext:    "00112233445566778899aabbccddeeff",
+				expectCipher: "69c4e0d86a7b0430d8cdb78070b4c55a",
+			},
+			{
+				key:          "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+				mode:         ECB,
+				plaintext:    "00112233445566778899aabbccddeeff",
+				expectCipher: "8ea2b7ca516745bfeafc49904b496089",
+			},
+			// NIST SP 800-38A F.2.1 CBC-AES128.Encrypt
+			{
+				key:          "2b7e151628aed2a6abf7158809cf4f3c",
+				mode:         CBC,
+				iv:           "000102030405060708090a0b0c0d0e0f",
+				plaintext:    "6bc1bee22e409f96e93d7e117393172a",
+				expectCipher: "7649abac8119b246cee98e9b12e9197d",
+			},
+		}
+	)
+
+	Convey("TEST Aes", t, func() {
+		for _, p := range pars {
+			key, _ := hex.DecodeString(p.key)
+			iv, _ := hex.DecodeString(p.iv)
+			plaintext, _ := hex.DecodeString(p.plaintext)
+			expectCipher, _ := hex.DecodeString(p.expectCipher)
+
+			c, err := NewAes(key, p.mode, iv, PAD_PKCS5)
+			So(err, ShouldBeNil)
+
+			ciphertext := c.Encrypt(plaintext)
+			So(ciphertext, ShouldHaveLength, len(plaintext)+16)
+			So(ciphertext[:len(plaintext)], ShouldResemble, expectCipher)
+
+			c, _ = NewAes(key, p.mode, iv, PAD_PKCS5)
+			So(c.Decrypt(ciphertext), ShouldResemble, plaintext)
+		}
+	})
+
+	Convey("TEST Aes invalid keys", t, func() {
+		_, err := NewAes(make([]byte, 8), ECB, nil, PAD_PKCS5)
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestBlockCipher(t *testing.T) {
+	Convey("TEST BlockCipher matches NewDes", t, func() {
+		b, _ := des.NewCipher([]byte("TANGTANG"))
+		c, err := NewBlockCipher(b, CBC, []byte("TANGTANG"), PAD_PKCS5)
+		So(err, ShouldBeNil)
+
+		d, _ := NewDes([]byte("TANGTANG"), CBC, []byte("TANGTANG"), PAD_PKCS5)
+		So(c.Encrypt([]byte("load test")), ShouldResemble, d.Encrypt([]byte("load test")))
+	})
+
+	Convey("TEST BlockCipher GCM needs a 16-byte block", t, func() {
+		b, _ := des.NewCipher([]byte("TANGTANG"))
+		_, err := NewBlockCipher(b, GCM, make([]byte, 12), PAD_PKCS5)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("TEST BlockCipher unsupported mode", t, func() {
+		b, _ := des.NewCipher([]byte("TANGTANG"))
+		_, err := NewBlockCipher(b, 9, nil, PAD_PKCS5)
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestStreamModes(t *testing.T) {
+	type params struct {
+		mode         uint8
+		iv           string
+		expectCipher string
+	}
+
+	var (
+		// NIST SP 800-38A F.3.13 CFB128, F.4.1 OFB and F.5.1 CTR with AES-128
+		key       = "2b7e151628aed2a6abf7158809cf4f3c"
+		plaintext = "6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e51"
+		pars      = []params{
+			{
+				mode:         CFB,
+				iv:           "000102030405060708090a0b0c0d0e0f",
+				expectCipher: "3b3fd92eb72dad20333449f8e83cfb4ac8a64537a0b3a93fcde3cdad9f1ce58b",
+			},
+			{
+				mode:         OFB,
+				iv:           "000102030405060708090a0b0c0d0e0f",
+				expectCipher: "3b3fd92eb72dad20333449f8e83cfb4a7789508d16918f03f53c52dac54ed825",
+			},
+			{
+				mode:         CTR,
+				iv:           "f0f1f2f3f4f5f6f7f8f9fafbfcfdfeff",
+				expectCipher: "874d6191b620e3261bef6864990db6ce9806f66b7970fdff8617187bb9fffdff",
+			},
+		}
+	)
+
+	Convey("TEST stream modes", t, func() {
+		for _, p := range pars {
+			k, _ := hex.DecodeString(key)
+			iv, _ := hex.DecodeString(p.iv)
+			expectCipher, _ := hex.DecodeString(p.expectCipher)
+
+			c, err := NewAes(k, p.mode, iv, PAD_PKCS5)
+			So(err, ShouldBeNil)
+
+			// No padding: an odd length is kept as is
+			for _, n := range []int{32, 21} {
+				pt, _ := hex.DecodeString(plaintext)
+				ciphertext := c.Encrypt(pt[:n])
+				So(ciphertext, ShouldResemble, expectCipher[:n])
+				So(c.Decrypt(ciphertext), ShouldResemble, pt[:n])
+			}
+		}
+	})
+}
+
+func TestGCM(t *testing.T) {
+	type params struct {
+		key          string
+		nonce        string
+		plaintext    string
+		aad          string
+		expectCipher string
+		expectTag    string
+	}
+
+	var (
+		// Test cases 2 and 4 of the GCM specification (McGrew and Viega)
+		pars = []params{
+			{
+				key:          "00000000000000000000000000000000",
+				nonce:        "000000000000000000000000",
+				plaintext:    "00000000000000000000000000000000",
+				expectCipher: "0388dace60b6a392f328c2b971b2fe78",
+				expectTag:    "ab6e47d42cec13bdf53a67b21257bddf",
+			},
+			{
+				key:          "feffe9928665731c6d6a8f9467308308",
+				nonce:        "cafebabefacedbaddecaf888",
+				plaintext:    "d9313225f88406e5a55909c5aff5269a86a7a9531534f7da2e4c303d8a318a721c3c0c95956809532fcf0e2449a6b525b16aedf5aa0de657ba637b39",
+				aad:          "feedfacedeadbeeffeedfacedeadbeefabaddad2",
+				expectCipher: "42831ec2217774244b7221b784d0d49ce3aa212f2c02a4e035c17e2329aca12e21d514b25466931c7d8f6a5aac84aa051ba30b396a0aac973d58e091",
+				expectTag:    "5bc94fbc3221a5db94fae95ae7121a47",
+			},
+		}
+	)
+
+	Convey("TEST GCM", t, func() {
+		for _, p := range pars {
+			key, _ := hex.DecodeString(p.key)
+			nonce, _ := hex.DecodeString(p.nonce)
+			plaintext, _ := hex.DecodeString(p.plaintext)
+			aad, _ := hex.DecodeString(p.aad)
+			expect, _ := hex.DecodeString(p.expectCipher + p.expectTag)
+
+			c, err := NewAes(key, GCM, nonce, PAD_PKCS5)
+			So(err, ShouldBeNil)
+			c.AllowFixedNonce()
+
+			ciphertext, err := c.Seal(plaintext, aad)
+			So(err, ShouldBeNil)
+			So(ciphertext, ShouldResemble, expect)
+
+			pt, err := c.Open(ciphertext, aad)
+			So(err, ShouldBeNil)
+			So(pt, ShouldResemble, plaintext)
+
+			// Flipping a bit of the ciphertext, the tag or the AAD fails authentication
+			for _, i := range []int{0, len(ciphertext) - 1} {
+				tampered := append([]byte{}, ciphertext...)
+				tampered[i] ^= 1
+				_, err = c.Open(tampered, aad)
+				So(errors.Cause(err), ShouldEqual, ErrAuthentication)
+				So(c.Decrypt(tampered), ShouldBeNil)
+			}
+			_, err = c.Open(ciphertext, append(aad, 0))
+			So(errors.Cause(err), ShouldEqual, ErrAuthentication)
+		}
+	})
+
+	Convey("TEST GCM draws a fresh nonce for every message", t, func() {
+		key, _ := hex.DecodeString("feffe9928665731c6d6a8f9467308308")
+		c, err := NewAes(key, GCM, nil, PAD_PKCS5)
+		So(err, ShouldBeNil)
+
+		c1, err := c.Seal([]byte("payload"), []byte("header"))
+		So(err, ShouldBeNil)
+		So(c1, ShouldHaveLength, 12+7+16)
+		c2 := c.NewSession().Encrypt([]byte("payload"))
+		So(c2[:12], ShouldNotResemble, c1[:12])
+
+		plaintext, err := c.Open(c1, []byte("header"))
+		So(err, ShouldBeNil)
+		So(plaintext, ShouldResemble, []byte("payload"))
+		So(c.Decrypt(c2), ShouldResemble, []byte("payload"))
+
+		c1[0] ^= 1
+		_, err = c.Open(c1, []byte("header"))
+		So(errors.Cause(err), ShouldEqual, ErrAuthentication)
+	})
+
+	Convey("TEST GCM seals with a fixed nonce only when allowed", t, func() {
+		key, _ := hex.DecodeString("feffe9928665731c6d6a8f9467308308")
+		c, err := NewAes(key, GCM, make([]byte, 12), PAD_PKCS5)
+		So(err, ShouldBeNil)
+		_, err = c.Seal([]byte("payload"), nil)
+		So(err, ShouldNotBeNil)
+		So(c.Encrypt([]byte("payload")), ShouldBeNil)
+		_, err = c.NewSession().Seal([]byte("payload"), nil)
+		So(err, ShouldNotBeNil)
+
+		c.AllowFixedNonce()
+		ciphertext, err := c.Seal([]byte("payload"), nil)
+		So(err, ShouldBeNil)
+		So(ciphertext, ShouldHaveLength, 7+16)
+	})
+}
+
+func TestSession(t *testing.T) {
+	Convey("TEST Session chains CBC across messages", t, func() {
+		des, _ := NewDes([]byte("TANGTANG"), CBC, []byte("12345678"), PAD_PKCS5)
+		s := des.NewSession()
+
+		c1 := s.Encrypt([]byte("first"))
+		c2 := s.Encrypt([]byte("second"))
+		So(c1, ShouldResemble, des.Encrypt([]byte("first")))
+
+		// The second message uses the last ciphertext block as IV
+		next, _ := NewDes([]byte("TANGTANG"), CBC, c1[len(c1)-8:], PAD_PKCS5)
+		So(c2, ShouldResemble, next.Encrypt([]byte("second")))
+
+		r := des.NewSession()
+		So(r.Decrypt(c1), ShouldResemble, []byte("first"))
+		So(r.Decrypt(c2), ShouldResemble, []byte("second"))
+	})
+
+	Convey("TEST Session keeps the CBC chain after a bad message", t, func() {
+		des, _ := NewDes([]byte("TANGTANG"), CBC, []byte("12345678"), PAD_PKCS5)
+		s := des.NewSession()
+		c1 := s.Encrypt([]byte("first"))
+		c2 := s.Encrypt([]byte("second"))
+
+		r := des.NewSession()
+		for _, bad := range [][]byte{append(append([]byte{}, c1[:7]...), c1[7]^0xff), c1[:7], {}} {
+			_, err := r.Open(bad, nil)
+			So(err, ShouldNotBeNil)
+		}
+		So(r.Decrypt(c1), ShouldResemble, []byte("first"))
+		So(r.Decrypt(c2), ShouldResemble, []byte("second"))
+	})
+
+	Convey("TEST Session continues the CTR keystream", t, func() {
+		key, _ := hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c")
+		c, _ := NewAes(key, CTR, make([]byte, 16), PAD_PKCS5)
+		s := c.NewSession()
+
+		whole := c.Encrypt([]byte("hello, session world"))
+		So(append(s.Encrypt([]byte("hello, ")), s.Encrypt([]byte("session world"))...), ShouldResemble, whole)
+		So(c.Encrypt([]byte("hello, ")), ShouldResemble, whole[:7])
+	})
+}
+
+func TestRandomIV(t *testing.T) {
+	Convey("TEST SetRandomIV prepends the IV", t, func() {
+		iv := []byte("abcdefghijklmnop")
+		d, err := NewDes([]byte("TANGTANG"), CBC|RANDOM_IV, nil, PAD_PKCS5)
+		So(err, ShouldBeNil)
+		d.SetRandomIV(bytes.NewReader(iv))
+
+		c1 := d.Encrypt([]byte("payload"))
+		c2 := d.Encrypt([]byte("payload"))
+		So(c1[:8], ShouldResemble, iv[:8])
+		So(c2[:8], ShouldResemble, iv[8:])
+
+		fixed, _ := NewDes([]byte("TANGTANG"), CBC, iv[:8], PAD_PKCS5)
+		So(c1[8:], ShouldResemble, fixed.Encrypt([]byte("payload")))
+
+		So(d.Decrypt(c1), ShouldResemble, []byte("payload"))
+		So(d.Decrypt(c2), ShouldResemble, []byte("payload"))
+
+		// The random source is exhausted
+		_, err = d.Seal([]byte("payload"), nil)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("TEST RANDOM_IV with crypto/rand", t, func() {
+		key, _ := hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c")
+		for _, mode := range []uint8{CBC, CFB, OFB, CTR, GCM} {
+			c, err := NewAes(key, mode|RANDOM_IV, nil, PAD_PKCS5)
+			So(err, ShouldBeNil)
+
+			c1, err := c.Seal([]byte("payload"), []byte("header"))
+			So(err, ShouldBeNil)
+			c2, _ := c.Seal([]byte("payload"), []byte("header"))
+			So(c1, ShouldNotResemble, c2)
+
+			plaintext, err := c.Open(c2, []byte("header"))
+			So(err, ShouldBeNil)
+			So(plaintext, ShouldResemble, []byte("payload"))
+		}
+	})
+
+	Convey("TEST SetRandomIV on a cipher with an IV", t, func() {
+		iv := []byte("abcdefgh")
+		d, _ := NewDes([]byte("TANGTANG"), CBC, []byte("12345678"), PAD_PKCS5)
+		d.SetRandomIV(bytes.NewReader(iv))
+
+		ciphertext := d.Encrypt([]byte("payload"))
+		So(ciphertext[:8], ShouldResemble, iv)
+		So(d.Decrypt(ciphertext), ShouldResemble, []byte("payload"))
+
+		// Sessions still chain from the constructor's IV
+		fixed, _ := NewDes([]byte("TANGTANG"), CBC, []byte("12345678"), PAD_PKCS5)
+		So(d.NewSession().Encrypt([]byte("payload")), ShouldResemble, fixed.Encrypt([]byte("payload")))
+	})
+
+	Convey("TEST SetRandomIV errors", t, func() {
+		// A missing IV is only accepted with RANDOM_IV
+		for _, mode := range []uint8{CBC, CFB, OFB, CTR} {
+			_, err := NewDes([]byte("TANGTANG"), mode, nil, PAD_PKCS5)
+			So(err, ShouldNotBeNil)
+			_, err = NewDes([]byte("TANGTANG"), mode|RANDOM_IV, make([]byte, 7), PAD_PKCS5)
+			So(err, ShouldNotBeNil)
+		}
+
+		d, err := NewDes([]byte("TANGTANG"), CBC|RANDOM_IV, nil, PAD_PKCS5)
+		So(err, ShouldBeNil)
+		_, err = d.NewSession().Seal([]byte("payload"), nil)
+		So(err, ShouldNotBeNil)
+		_, err = d.Open(make([]byte, 7), nil)
+		So(err, ShouldNotBeNil)
+
+		d.SetRandomIV(iotest.ErrReader(io.ErrUnexpectedEOF))
+		_, err = d.Seal([]byte("payload"), nil)
+		So(err, ShouldNotBeNil)
+
+		// ECB has no IV to prepend
+		e, _ := NewDes([]byte("TANGTANG"), ECB|RANDOM_IV, nil, PAD_PKCS5)
+		So(e.Encrypt([]byte("payload")), ShouldHaveLength, 8)
+	})
+}
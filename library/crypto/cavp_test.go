@@ -0,0 +1,322 @@
+package crypto
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// The response files in testdata are in the CAVP format but were computed
+// locally with OpenSSL; they are not the official NIST files. Records whose
+// inputs equal published ones are checked against the published results.
+
+// rspRecord is one COUNT block of a CAVP response file.
+type rspRecord struct {
+	Section string
+	Count   string
+	Values  map[string][]byte
+}
+
+// parseRsp reads a CAVP response file: "#" comments, "[SECTION]" headers
+// and records of "NAME = hex" lines separated by blank lines.
+func parseRsp(path string) (records []rspRecord, err error) {
+	var (
+		f       *os.File
+		section string
+		rec     *rspRecord
+	)
+
+	if f, err = os.Open(path); err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+			rec = nil
+		case line[0] == '#':
+		case line[0] == '[':
+			section, rec = strings.Trim(line, "[]"), nil
+		default:
+			kv := strings.SplitN(line, "=", 2)
+			if len(kv) != 2 {
+				return nil, errors.Errorf("%s:%d: expected NAME = value", path, n)
+			}
+			name, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+			if rec == nil {
+				records = append(records, rspRecord{Section: section, Values: map[string][]byte{}})
+				rec = &records[len(records)-1]
+			}
+			if name == "COUNT" {
+				rec.Count = value
+				continue
+			}
+			if rec.Values[name], err = hex.DecodeString(value); err != nil {
+				return nil, errors.Wrapf(err, "%s:%d", path, n)
+			}
+		}
+	}
+
+	return records, scanner.Err()
+}
+
+// rspCipher builds the cipher of a record: AES from KEY, TDES from KEYs or KEY1-3.
+func rspCipher(r rspRecord, mode uint8) (*BlockCipher, error) {
+	if key, ok := r.Values["KEY"]; ok {
+		return NewAes(key, mode, r.Values["IV"], PAD_NONE)
+	}
+	if key, ok := r.Values["KEYs"]; ok {
+		return NewTripleDes(append(append(append([]byte{}, key...), key...), key...), mode, r.Values["IV"], PAD_NONE)
+	}
+	key := append(append(append([]byte{}, r.Values["KEY1"]...), r.Values["KEY2"]...), r.Values["KEY3"]...)
+	return NewTripleDes(key, mode, r.Values["IV"], PAD_NONE)
+}
+
+func TestCAVP(t *testing.T) {
+	type Case struct {
+		file string
+		mode uint8
+	}
+
+	var (
+		cases = []Case{
+			{"ECBVarTxt128.rsp", ECB},
+			{"CBCMMT128.rsp", CBC},
+			{"TECBvartext.rsp", ECB},
+			{"TECBinvperm.rsp", ECB},
+			{"TECBvarkey.rsp", ECB},
+			{"TCBCvartext.rsp", CBC},
+			{"TCBCinvperm.rsp", CBC},
+			{"TCBCvarkey.rsp", CBC},
+			{"TECBMMT2.rsp", ECB},
+			{"TECBMMT3.rsp", ECB},
+			{"TCBCMMT2.rsp", CBC},
+			{"TCBCMMT3.rsp", CBC},
+		}
+		// Published results of records with the same inputs: AESAVS appendix
+		// D.1, SP 800-38A F.2.1, the AES Monte Carlo response files and
+		// SP 800-17 tables B.1 and B.2
+		published = []struct {
+			file, section string
+			count         int
+			name, value   string
+		}{
+			{"ECBVarTxt128.rsp", "ENCRYPT", 0, "CIPHERTEXT", "3ad78e726c1ec02b7ebfe92b23d9ec34"},
+			{"CBCMMT128.rsp", "ENCRYPT", 0, "CIPHERTEXT", "7649abac8119b246cee98e9b12e9197d5086cb9b507219ee95db113a917678b2" +
+				"73bed6b8e3c1743b7116e69e222295163ff1caa1681fac09120eca307586e1a7"},
+			{"ECBMCT128.rsp", "ENCRYPT", 0, "CIPHERTEXT", "d7c3ffac9031238650901e157364c386"},
+			{"CBCMCT128.rsp", "ENCRYPT", 0, "CIPHERTEXT", "1b1ebd1fc45ec43037fd4844241a437f"},
+			{"TECBvartext.rsp", "ENCRYPT", 0, "CIPHERTEXT", "95f8a5e5dd31d900"},
+			{"TECBvartext.rsp", "ENCRYPT", 1, "CIPHERTEXT", "dd7f121ca5015619"},
+			{"TECBvartext.rsp", "ENCRYPT", 2, "CIPHERTEXT", "2e8653104f3834ea"},
+			{"TECBvarkey.rsp", "ENCRYPT", 0, "CIPHERTEXT", "95a8d72813daa94d"},
+			{"TECBvarkey.rsp", "ENCRYPT", 1, "CIPHERTEXT", "0eec1487dd8c26d5"},
+			{"TECBinvperm.rsp", "ENCRYPT", 0, "CIPHERTEXT", "8000000000000000"},
+		}
+	)
+
+	Convey("TEST CAVP known answer and multi-block message tests", t, func() {
+		for _, c := range cases {
+			records, err := parseRsp(filepath.Join("testdata", c.file))
+			So(err, ShouldBeNil)
+			So(len(records), ShouldBeGreaterThan, 0)
+
+			for _, r := range records {
+				bc, err := rspCipher(r, c.mode)
+				So(err, ShouldBeNil)
+
+				rspCheck(bc, r)
+
+				// TDES with one key for all three is single DES
+				if key, ok := r.Values["KEYs"]; ok {
+					bc, err = NewDes(key, c.mode, r.Values["IV"], PAD_NONE)
+					So(err, ShouldBeNil)
+					rspCheck(bc, r)
+				}
+			}
+		}
+	})
+
+	Convey("TEST CAVP records match the published results", t, func() {
+		for _, p := range published {
+			records, err := parseRsp(filepath.Join("testdata", p.file))
+			So(err, ShouldBeNil)
+
+			var found bool
+			for _, r := range records {
+				if r.Section == p.section && r.Count == strconv.Itoa(p.count) {
+					So(hex.EncodeToString(r.Values[p.name]), ShouldEqual, p.value)
+					found = true
+				}
+			}
+			So(found, ShouldBeTrue)
+		}
+	})
+
+	Convey("TEST CAVP ECB Monte Carlo test", t, func() {
+		records, err := parseRsp(filepath.Join("testdata", "ECBMCT128.rsp"))
+		So(err, ShouldBeNil)
+		So(records, ShouldHaveLength, 100)
+
+		key, pt := records[0].Values["KEY"], records[0].Values["PLAINTEXT"]
+		for _, r := range records {
+			So(key, ShouldResemble, r.Values["KEY"])
+			So(pt, ShouldResemble, r.Values["PLAINTEXT"])
+
+			c, _ := NewAes(key, ECB, nil, PAD_NONE)
+			ct := pt
+			for j := 0; j < 1000; j++ {
+				ct = c.Encrypt(ct)
+			}
+			So(ct, ShouldResemble, r.Values["CIPHERTEXT"])
+
+			key = append([]byte{}, key...)
+			xorBytes(key, ct)
+			pt = ct
+		}
+	})
+
+	Convey("TEST CAVP CBC Monte Carlo test", t, func() {
+		records, err := parseRsp(filepath.Join("testdata", "CBCMCT128.rsp"))
+		So(err, ShouldBeNil)
+		So(records, ShouldHaveLength, 100)
+
+		key, iv, pt := records[0].Values["KEY"], records[0].Values["IV"], records[0].Values["PLAINTEXT"]
+		for _, r := range records {
+			So(key, ShouldResemble, r.Values["KEY"])
+			So(iv, ShouldResemble, r.Values["IV"])
+			So(pt, ShouldResemble, r.Values["PLAINTEXT"])
+
+			// A Session chains the 1000 single-block messages as CBC does
+			c, _ := NewAes(key, CBC, iv, PAD_NONE)
+			s := c.NewSession()
+			ct := make([][]byte, 1000)
+			p := pt
+			for j := range ct {
+				ct[j] = s.Encrypt(p)
+				if j == 0 {
+					p = iv
+				} else {
+					p = ct[j-1]
+				}
+			}
+			So(ct[999], ShouldResemble, r.Values["CIPHERTEXT"])
+
+			key = append([]byte{}, key...)
+			xorBytes(key, ct[999])
+			iv, pt = ct[999], ct[998]
+		}
+	})
+
+	Convey("TEST CAVP TDES Monte Carlo tests", t, func() {
+		for _, c := range []Case{
+			{"TECBMonte1.rsp", ECB},
+			{"TECBMonte2.rsp", ECB},
+			{"TCBCMonte1.rsp", CBC},
+			{"TCBCMonte2.rsp", CBC},
+		} {
+			records, err := parseRsp(filepath.Join("testdata", c.file))
+			So(err, ShouldBeNil)
+			So(records, ShouldHaveLength, 800)
+			tdesMonte(records[:400], c.mode, true)
+			tdesMonte(records[400:], c.mode, false)
+		}
+	})
+
+	Convey("TEST parseRsp", t, func() {
+		_, err := parseRsp(filepath.Join("testdata", "missing.rsp"))
+		So(err, ShouldNotBeNil)
+	})
+}
+
+// rspCheck encrypts or decrypts the input of a record as its section says.
+func rspCheck(bc *BlockCipher, r rspRecord) {
+	if r.Section == "ENCRYPT" {
+		got, err := bc.Seal(r.Values["PLAINTEXT"], nil)
+		So(err, ShouldBeNil)
+		So(got, ShouldResemble, r.Values["CIPHERTEXT"])
+	} else {
+		got, err := bc.Open(r.Values["CIPHERTEXT"], nil)
+		So(err, ShouldBeNil)
+		So(got, ShouldResemble, r.Values["PLAINTEXT"])
+	}
+}
+
+// tdesMonte runs the TDESAVS Monte Carlo test over the records of one section,
+// each round starting from the keys and text left by the previous one.
+func tdesMonte(records []rspRecord, mode uint8, encrypt bool) {
+	in, out := "PLAINTEXT", "CIPHERTEXT"
+	if !encrypt {
+		in, out = out, in
+	}
+
+	first := records[0].Values
+	k1, k2, k3, iv, x := first["KEY1"], first["KEY2"], first["KEY3"], first["IV"], first[in]
+	for _, r := range records {
+		So(k1, ShouldResemble, r.Values["KEY1"])
+		So(k2, ShouldResemble, r.Values["KEY2"])
+		So(k3, ShouldResemble, r.Values["KEY3"])
+		So(iv, ShouldResemble, r.Values["IV"])
+		So(x, ShouldResemble, r.Values[in])
+
+		// A Session chains the 10000 single-block messages as CBC does
+		c, err := NewTripleDes(append(append(append([]byte{}, k1...), k2...), k3...), mode, iv, PAD_NONE)
+		So(err, ShouldBeNil)
+		s := c.NewSession()
+		res := make([][]byte, 10000)
+		p := x
+		for j := range res {
+			if encrypt {
+				res[j] = s.Encrypt(p)
+			} else {
+				res[j] = s.Decrypt(p)
+			}
+			switch {
+			case mode == ECB || !encrypt:
+				p = res[j]
+			case j == 0:
+				p = iv
+			default:
+				p = res[j-1]
+			}
+		}
+		So(res[9999], ShouldResemble, r.Values[out])
+
+		// K1 ^= res[9999], K2 ^= res[9998], then K3 ^= res[9997] with
+		// three keys or K3 = K1 with two
+		threeKeys := !bytes.Equal(k1, k3)
+		k1 = SetDesParity(xorCopy(k1, res[9999]))
+		k2 = SetDesParity(xorCopy(k2, res[9998]))
+		if threeKeys {
+			k3 = SetDesParity(xorCopy(k3, res[9997]))
+		} else {
+			k3 = k1
+		}
+		switch {
+		case mode == ECB:
+			x = res[9999]
+		case encrypt:
+			iv, x = res[9999], res[9998]
+		default:
+			iv, x = res[9998], res[9999]
+		}
+	}
+}
+
+func xorCopy(a, b []byte) []byte {
+	a = append([]byte{}, a...)
+	xorBytes(a, b)
+	return a
+}
@@ -8,24 +8,8 @@ import (
 	"github.com/pkg/errors"
 )
 
-const (
-	// Modes of crypting / cyphering
-	ECB = 0
-	CBC = 1
-
-	// Modes of padding
-	PAD_NORMAL = 1
-	PAD_PKCS5  = 2
-)
-
-// Des def
-type Des struct {
-	mode    uint8
-	padmode uint8
-	b       cipher.Block
-	ebm     cipher.BlockMode
-	dbm     cipher.BlockMode
-}
+// Des is a BlockCipher over DES or Triple DES.
+type Des = BlockCipher
 
 func PKCS5Padding(src []byte, blockSize int) []byte {
 	padding := blockSize - len(src)%blockSize
@@ -33,10 +17,13 @@ func PKCS5Padding(src []byte, blockSize int) []byte {
 	return append(src, padtext...)
 }
 
+// PKCS5UnPadding strips PKCS5 padding, returning nil if src is not padded.
 func PKCS5UnPadding(src []byte) []byte {
-	length := len(src)
-	unpadding := int(src[length-1])
-	return src[:(length - unpadding)]
+	if len(src) == 0 {
+		return nil
+	}
+	src, _ = PKCS7.Unpad(src, len(src))
+	return src
 }
 
 func ZeroPadding(ciphertext []byte, blockSize int) []byte {
@@ -45,93 +32,40 @@ func ZeroPadding(ciphertext []byte, blockSize int) []byte {
 	return append(ciphertext, padtext...)
 }
 
+// ZeroUnPadding strips trailing zero bytes, including any that were part of the message.
 func ZeroUnPadding(src []byte) []byte {
-	return bytes.TrimFunc(src,
-		func(r rune) bool {
-			return r == rune(0)
-		})
+	return bytes.TrimRight(src, "\x00")
 }
 
 func NewDes(key []byte, mode uint8, iv []byte, padmode uint8) (d *Des, err error) {
-	var (
-		b   cipher.Block
-		ebm cipher.BlockMode
-		dbm cipher.BlockMode
-	)
+	var b cipher.Block
 
 	if b, err = des.NewCipher(key); err != nil {
 		err = errors.Wrapf(err, "des.NewCipher failed")
 		return
 	}
 
-	if mode == CBC {
-		ebm = cipher.NewCBCEncrypter(b, iv)
-		dbm = cipher.NewCBCDecrypter(b, iv)
-	}
-
-	d = &Des{
-		b:       b,
-		ebm:     ebm,
-		dbm:     dbm,
-		mode:    mode,
-		padmode: padmode,
-	}
-
-	return
+	return NewBlockCipher(b, mode, iv, padmode)
 }
 
-func (d *Des) Encrypt(plaintext []byte) (ciphertext []byte) {
-	var (
-		bs  = d.b.BlockSize()
-		dst []byte
-	)
-
-	if d.padmode == PAD_PKCS5 {
-		plaintext = PKCS5Padding(plaintext, bs)
-	} else {
-		plaintext = ZeroPadding(plaintext, bs)
-	}
-
-	ciphertext = make([]byte, len(plaintext))
-
-	if d.mode == CBC {
-		d.ebm.CryptBlocks(ciphertext, plaintext)
-	} else if d.mode == ECB {
-		dst = ciphertext
-		for len(plaintext) > 0 {
-			d.b.Encrypt(dst, plaintext[:bs])
-			plaintext = plaintext[bs:]
-			dst = dst[bs:]
-		}
-	}
-
-	return
-}
-
-func (d *Des) Decrypt(ciphertext []byte) (plaintext []byte) {
-	var (
-		bs  = d.b.BlockSize()
-		dst []byte
-	)
-
-	plaintext = make([]byte, len(ciphertext))
-
-	if d.mode == CBC {
-		d.dbm.CryptBlocks(plaintext, ciphertext)
-	} else if d.mode == ECB {
-		dst = plaintext
-		for len(ciphertext) > 0 {
-			d.b.Decrypt(dst, ciphertext[:bs])
-			ciphertext = ciphertext[bs:]
-			dst = dst[bs:]
-		}
+// NewTripleDes is NewDes for Triple DES (3DES-EDE). The key holds three 8-byte
+// DES keys K1 K2 K3, or two (K1 K2) in which case K3 = K1.
+func NewTripleDes(key []byte, mode uint8, iv []byte, padmode uint8) (d *Des, err error) {
+	var b cipher.Block
+
+	switch len(key) {
+	case 16:
+		key = append(append([]byte{}, key...), key[:8]...)
+	case 24:
+	default:
+		err = errors.Errorf("3DES key must be 16 or 24 bytes, got %d", len(key))
+		return
 	}
 
-	if d.padmode == PAD_PKCS5 {
-		plaintext = PKCS5UnPadding(plaintext)
-	} else {
-		plaintext = ZeroUnPadding(plaintext)
+	if b, err = des.NewTripleDESCipher(key); err != nil {
+		err = errors.Wrapf(err, "des.NewTripleDESCipher failed")
+		return
 	}
 
-	return
+	return NewBlockCipher(b, mode, iv, padmode)
 }
@@ -1,6 +1,9 @@
 package crypto
 
 import (
+	"crypto/des"
+	"encoding/hex"
+	"sync"
 	"testing"
 
 	. "github.com/smartystreets/goconvey/convey"
@@ -63,3 +66,176 @@ func TestDesEncryptDecrypt(t *testing.T) {
 		}
 	})
 }
+
+func TestTripleDes(t *testing.T) {
+	type params struct {
+		key          string
+		mode         uint8
+		iv           string
+		plaintext    string
+		expectCipher string
+	}
+
+	var (
+		fox  = "54686520717566636b2062726f776e20666f78206a756d70"
+		pars = []params{
+			// NIST SP 800-67 example
+			{
+				key:          "0123456789abcdef23456789abcdef01456789abcdef0123",
+				mode:         ECB,
+				plaintext:    fox,
+				expectCipher: "a826fd8ce53b855fcce21c8112256fe668d5c05dd9b6b900",
+			},
+			// NIST SP 800-20 variable plaintext and variable key known answers, K1 = K2 = K3
+			{
+				key:          "010101010101010101010101010101010101010101010101",
+				mode:         ECB,
+				plaintext:    "80000000000000004000000000000000",
+				expectCipher: "95f8a5e5dd31d900dd7f121ca5015619",
+			},
+			{
+				key:          "01010101010101010101010101010101",
+				mode:         ECB,
+				plaintext:    "20000000000000001000000000000000",
+				expectCipher: "2e8653104f3834ea4bd388ff6cd81d4f",
+			},
+			{
+				key:          "800101010101010180010101010101018001010101010101",
+				mode:         ECB,
+				plaintext:    "0000000000000000",
+				expectCipher: "95a8d72813daa94d",
+			},
+			// Two-key and CBC cross-checked with OpenSSL des-ede and des-ede3-cbc
+			{
+				key:          "0123456789abcdef23456789abcdef01",
+				mode:         ECB,
+				plaintext:    fox,
+				expectCipher: "c44862f70cf2fbdc9077d0909fa91b884cabd61fc58e0cbb",
+			},
+			{
+				key:          "0123456789abcdef23456789abcdef01456789abcdef0123",
+				mode:         CBC,
+				iv:           "f69f2445df4f9b17",
+				plaintext:    fox,
+				expectCipher: "a5c282bad0de3774becd2e04386b589fb5057d8552fc4336",
+			},
+		}
+	)
+
+	Convey("TEST TripleDes", t, func() {
+		for _, p := range pars {
+			key, _ := hex.DecodeString(p.key)
+			iv, _ := hex.DecodeString(p.iv)
+			plaintext, _ := hex.DecodeString(p.plaintext)
+			expectCipher, _ := hex.DecodeString(p.expectCipher)
+
+			tdes, err := NewTripleDes(key, p.mode, iv, PAD_PKCS5)
+			So(err, ShouldBeNil)
+
+			// PKCS5 adds a whole block of padding to aligned plaintext
+			ciphertext := tdes.Encrypt(plaintext)
+			So(ciphertext, ShouldHaveLength, len(plaintext)+8)
+			So(ciphertext[:len(plaintext)], ShouldResemble, expectCipher)
+
+			tdes, _ = NewTripleDes(key, p.mode, iv, PAD_PKCS5)
+			So(tdes.Decrypt(ciphertext), ShouldResemble, plaintext)
+		}
+	})
+
+	Convey("TEST TripleDes invalid keys", t, func() {
+		for _, n := range []int{0, 8, 15, 17, 32} {
+			_, err := NewTripleDes(make([]byte, n), ECB, nil, PAD_PKCS5)
+			So(err, ShouldNotBeNil)
+		}
+	})
+}
+
+func TestDesStateless(t *testing.T) {
+	Convey("TEST Des calls are independent", t, func() {
+		for _, mode := range []uint8{ECB, CBC} {
+			des, err := NewDes([]byte("TANGTANG"), mode, []byte("12345678"), PAD_PKCS5)
+			So(err, ShouldBeNil)
+
+			first := des.Encrypt([]byte("virtual user 1"))
+			So(des.Encrypt([]byte("virtual user 1")), ShouldResemble, first)
+			So(des.Decrypt(first), ShouldResemble, []byte("virtual user 1"))
+			So(des.Decrypt(first), ShouldResemble, []byte("virtual user 1"))
+		}
+	})
+
+	Convey("TEST Des shared between goroutines", t, func() {
+		var (
+			wg       sync.WaitGroup
+			des, _   = NewDes([]byte("TANGTANG"), CBC, []byte("12345678"), PAD_PKCS5)
+			plain    = []byte("a payload longer than a single DES block")
+			expect   = des.Encrypt(plain)
+			failures = make(chan int, 64)
+		)
+
+		for i := 0; i < 64; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					c := des.Encrypt(plain)
+					if string(c) != string(expect) || string(des.Decrypt(c)) != string(plain) {
+						failures <- i
+						return
+					}
+				}
+			}(i)
+		}
+		wg.Wait()
+		close(failures)
+
+		So(failures, ShouldBeEmpty)
+	})
+}
+
+func TestDesErrors(t *testing.T) {
+	Convey("TEST NewDes rejects wrong-length IVs", t, func() {
+		for _, n := range []int{0, 7, 9, 16} {
+			_, err := NewDes([]byte("TANGTANG"), CBC, make([]byte, n), PAD_PKCS5)
+			So(err, ShouldNotBeNil)
+		}
+		_, err := NewDes([]byte("TANGTANG"), ECB, nil, PAD_PKCS5)
+		So(err, ShouldBeNil)
+	})
+
+	Convey("TEST Open rejects malformed ciphertext", t, func() {
+		var (
+			b, _ = des.NewCipher([]byte("TANGTANG"))
+			raw  = func(block string) []byte {
+				dst := make([]byte, 8)
+				b.Encrypt(dst, []byte(block))
+				return dst
+			}
+			cases = [][]byte{
+				nil,
+				make([]byte, 7),
+				make([]byte, 9),
+				raw("1234567\x00"),       // zero padding length
+				raw("1234567\x09"),       // padding longer than the block
+				raw("12345\x03\x01\x03"), // inconsistent pad bytes
+			}
+		)
+
+		d, _ := NewDes([]byte("TANGTANG"), ECB, nil, PAD_PKCS5)
+		for _, c := range cases {
+			plaintext, err := d.Open(c, nil)
+			So(err, ShouldNotBeNil)
+			So(plaintext, ShouldBeNil)
+			So(d.Decrypt(c), ShouldBeNil)
+		}
+
+		plaintext, err := d.Open(raw("12345\x03\x03\x03"), nil)
+		So(err, ShouldBeNil)
+		So(plaintext, ShouldResemble, []byte("12345"))
+	})
+
+	Convey("TEST PKCS5UnPadding does not panic", t, func() {
+		So(PKCS5UnPadding(nil), ShouldBeNil)
+		So(PKCS5UnPadding([]byte{1, 2, 9}), ShouldBeNil)
+		So(PKCS5UnPadding([]byte{1, 2, 2}), ShouldResemble, []byte{1})
+	})
+}
@@ -0,0 +1,131 @@
+package crypto
+
+import (
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// MinTagSize and MaxTagSize bound the HMAC-SHA256 tag length of an Envelope.
+	MinTagSize = 10
+	MaxTagSize = sha256.Size
+)
+
+// Envelope authenticates the output of a Cipher in encrypt-then-MAC order:
+// the ciphertext is followed by an HMAC-SHA256 tag, possibly truncated, over
+// the additional data and the ciphertext. Open checks the tag in constant
+// time before decrypting anything.
+type Envelope struct {
+	c       Cipher
+	macKey  []byte
+	tagSize int
+}
+
+var _ Cipher = (*Envelope)(nil)
+
+// NewEnvelope wraps c. tagSize is from MinTagSize to MaxTagSize, 0 for MaxTagSize.
+// macKey must not be the key of c; DeriveEnvelope derives both from one key.
+func NewEnvelope(c Cipher, macKey []byte, tagSize int) (e *Envelope, err error) {
+	if tagSize == 0 {
+		tagSize = MaxTagSize
+	}
+	if tagSize < MinTagSize || tagSize > MaxTagSize {
+		err = errors.Errorf("tag size must be %d to %d bytes, got %d", MinTagSize, MaxTagSize, tagSize)
+		return
+	}
+	if len(macKey) == 0 {
+		err = errors.New("empty MAC key")
+		return
+	}
+
+	e = &Envelope{
+		c:       c,
+		macKey:  clone(macKey),
+		tagSize: tagSize,
+	}
+
+	return
+}
+
+// DeriveEnvelope derives an encryption key of encKeySize bytes and a 32-byte
+// MAC key from master with HKDF-SHA256, and builds the Envelope around the
+// cipher newCipher makes from the encryption key, e.g.
+//
+//	DeriveEnvelope(master, 8, func(k []byte) (Cipher, error) { return NewDes(k, CBC, iv, PAD_PKCS5) }, 0)
+func DeriveEnvelope(master []byte, encKeySize int, newCipher func(encKey []byte) (Cipher, error), tagSize int) (e *Envelope, err error) {
+	var (
+		encKey []byte
+		macKey []byte
+		c      Cipher
+	)
+
+	if encKey, err = hkdf.Key(sha256.New, master, nil, "envelope encryption", encKeySize); err != nil {
+		err = errors.Wrapf(err, "deriving encryption key failed")
+		return
+	}
+	if macKey, err = hkdf.Key(sha256.New, master, nil, "envelope mac", sha256.Size); err != nil {
+		err = errors.Wrapf(err, "deriving MAC key failed")
+		return
+	}
+	if c, err = newCipher(encKey); err != nil {
+		return
+	}
+
+	return NewEnvelope(c, macKey, tagSize)
+}
+
+// tag is HMAC-SHA256(len(additionalData) || additionalData || ciphertext),
+// the length as 8 big-endian bytes, truncated to tagSize.
+func (e *Envelope) tag(ciphertext, additionalData []byte) []byte {
+	var (
+		mac = hmac.New(sha256.New, e.macKey)
+		n   [8]byte
+	)
+
+	binary.BigEndian.PutUint64(n[:], uint64(len(additionalData)))
+	mac.Write(n[:])
+	mac.Write(additionalData)
+	mac.Write(ciphertext)
+
+	return mac.Sum(nil)[:e.tagSize]
+}
+
+func (e *Envelope) Encrypt(plaintext []byte) (ciphertext []byte) {
+	ciphertext, _ = e.Seal(plaintext, nil)
+	return
+}
+
+func (e *Envelope) Decrypt(ciphertext []byte) (plaintext []byte) {
+	plaintext, _ = e.Open(ciphertext, nil)
+	return
+}
+
+// Seal encrypts plaintext and appends the tag over additionalData and the ciphertext.
+func (e *Envelope) Seal(plaintext, additionalData []byte) (ciphertext []byte, err error) {
+	if ciphertext, err = e.c.Seal(plaintext, additionalData); err != nil {
+		return
+	}
+
+	return append(ciphertext, e.tag(ciphertext, additionalData)...), nil
+}
+
+// Open verifies the tag and only then decrypts. A tag mismatch is
+// ErrAuthentication.
+func (e *Envelope) Open(ciphertext, additionalData []byte) (plaintext []byte, err error) {
+	if len(ciphertext) < e.tagSize {
+		err = errors.Errorf("message is shorter than the %d-byte tag", e.tagSize)
+		return
+	}
+
+	body, tag := ciphertext[:len(ciphertext)-e.tagSize], ciphertext[len(ciphertext)-e.tagSize:]
+	if !hmac.Equal(tag, e.tag(body, additionalData)) {
+		err = ErrAuthentication
+		return
+	}
+
+	return e.c.Open(body, additionalData)
+}
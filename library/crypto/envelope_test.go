@@ -0,0 +1,95 @@
+package crypto
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/pkg/errors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestEnvelope(t *testing.T) {
+	var (
+		des, _    = NewDes([]byte("TANGTANG"), CBC, []byte("12345678"), PAD_PKCS5)
+		macKey    = []byte("a MAC key, not the DES key")
+		plaintext = []byte("transfer 100 to account 42")
+		header    = []byte("v1")
+	)
+
+	Convey("TEST Envelope is encrypt-then-MAC", t, func() {
+		e, err := NewEnvelope(des, macKey, 0)
+		So(err, ShouldBeNil)
+
+		sealed, err := e.Seal(plaintext, header)
+		So(err, ShouldBeNil)
+
+		body := sealed[:len(sealed)-32]
+		So(body, ShouldResemble, des.Encrypt(plaintext))
+
+		mac := hmac.New(sha256.New, macKey)
+		mac.Write([]byte{0, 0, 0, 0, 0, 0, 0, 2})
+		mac.Write(header)
+		mac.Write(body)
+		So(sealed[len(body):], ShouldResemble, mac.Sum(nil))
+
+		opened, err := e.Open(sealed, header)
+		So(err, ShouldBeNil)
+		So(opened, ShouldResemble, plaintext)
+		So(e.Decrypt(e.Encrypt(plaintext)), ShouldResemble, plaintext)
+	})
+
+	Convey("TEST Envelope detects tampering", t, func() {
+		e, _ := NewEnvelope(des, macKey, 12)
+		sealed, _ := e.Seal(plaintext, header)
+		So(sealed, ShouldHaveLength, len(des.Encrypt(plaintext))+12)
+
+		for i := range sealed {
+			tampered := append([]byte{}, sealed...)
+			tampered[i] ^= 0x80
+			_, err := e.Open(tampered, header)
+			So(errors.Cause(err), ShouldEqual, ErrAuthentication)
+		}
+
+		_, err := e.Open(sealed, []byte("v2"))
+		So(errors.Cause(err), ShouldEqual, ErrAuthentication)
+		_, err = e.Open(sealed[:11], header)
+		So(err, ShouldNotBeNil)
+		So(e.Decrypt(sealed), ShouldBeNil)
+
+		other, _ := NewEnvelope(des, []byte("another MAC key"), 12)
+		_, err = other.Open(sealed, header)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("TEST Envelope tag sizes", t, func() {
+		for _, n := range []int{-1, 1, MinTagSize - 1, MaxTagSize + 1} {
+			_, err := NewEnvelope(des, macKey, n)
+			So(err, ShouldNotBeNil)
+		}
+		_, err := NewEnvelope(des, nil, 0)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("TEST DeriveEnvelope", t, func() {
+		var encKey []byte
+		newDes := func(key []byte) (Cipher, error) {
+			encKey = key
+			return NewDes(key, CBC, []byte("12345678"), PAD_PKCS5)
+		}
+
+		e1, err := DeriveEnvelope([]byte("master secret"), 8, newDes, 16)
+		So(err, ShouldBeNil)
+		e2, _ := DeriveEnvelope([]byte("master secret"), 8, newDes, 16)
+		e3, _ := DeriveEnvelope([]byte("other secret"), 8, newDes, 16)
+
+		sealed := e1.Encrypt(plaintext)
+		So(e2.Decrypt(sealed), ShouldResemble, plaintext)
+		So(e3.Decrypt(sealed), ShouldBeNil)
+
+		// Separate keys for encryption and MAC
+		So(encKey, ShouldHaveLength, 8)
+		So(e3.macKey, ShouldHaveLength, 32)
+		So(e3.macKey[:8], ShouldNotResemble, encKey)
+	})
+}
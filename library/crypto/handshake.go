@@ -0,0 +1,245 @@
+package crypto
+
+import (
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+
+	"github.com/pkg/errors"
+)
+
+// handshakeLabel starts the transcript so that hashes of this protocol
+// cannot be confused with any other.
+const handshakeLabel = "load X25519 handshake v1"
+
+// HandshakeConfig says what cipher the negotiated session keys are for.
+type HandshakeConfig struct {
+	// KeySize is the session key length in bytes, e.g. 8 for DES or 16 for AES-128.
+	KeySize int
+	// NewCipher builds a session cipher from a session key. It is called once
+	// per direction, so each side seals with its own key and opens with the
+	// peer's.
+	NewCipher func(key []byte) (Cipher, error)
+	// PSK is an optional key shared in advance by client and server. Without
+	// it the handshake is not authenticated, see below.
+	PSK []byte
+}
+
+// The handshake runs in three messages:
+//
+//	client hello:    client X25519 public key (32 bytes)
+//	server hello:    server X25519 public key (32 bytes), server finished (32 bytes)
+//	client finished: 32 bytes
+//
+// The transcript hash is SHA-256 over the label and both public keys. The
+// client and server write keys and both finished keys are derived with
+// HKDF-SHA256 from the shared secret followed by the PSK, salted with the
+// transcript hash; each finished message is the HMAC of the transcript hash
+// under its key. A public key changed in transit gives the two sides
+// different secrets, so the finished check fails.
+//
+// The public keys themselves are not authenticated: without a PSK an active
+// man in the middle can run one handshake with each side and relay the
+// traffic, and the finished checks pass. Set a PSK, or authenticate the
+// public keys by other means, where that matters.
+type handshake struct {
+	cfg        HandshakeConfig
+	transcript []byte
+	clientKey  []byte
+	serverKey  []byte
+	clientMAC  []byte
+	serverMAC  []byte
+}
+
+func (h *handshake) derive(secret, clientPub, serverPub []byte) (err error) {
+	th := sha256.New()
+	th.Write([]byte(handshakeLabel))
+	th.Write(clientPub)
+	th.Write(serverPub)
+	h.transcript = th.Sum(nil)
+
+	secret = append(append([]byte{}, secret...), h.cfg.PSK...)
+
+	for _, k := range []struct {
+		info string
+		key  *[]byte
+	}{{"client write key", &h.clientKey}, {"server write key", &h.serverKey}} {
+		if *k.key, err = hkdf.Key(sha256.New, secret, h.transcript, k.info, h.cfg.KeySize); err != nil {
+			return errors.Wrapf(err, "deriving session key failed")
+		}
+	}
+
+	for _, k := range []struct {
+		info string
+		mac  *[]byte
+	}{{"client finished", &h.clientMAC}, {"server finished", &h.serverMAC}} {
+		key, err := hkdf.Key(sha256.New, secret, h.transcript, k.info, sha256.Size)
+		if err != nil {
+			return errors.Wrapf(err, "deriving finished key failed")
+		}
+		mac := hmac.New(sha256.New, key)
+		mac.Write(h.transcript)
+		*k.mac = mac.Sum(nil)
+	}
+
+	return nil
+}
+
+// sessionCipher seals with the key of one side and opens with the other's,
+// so that the two directions never share a key and nonce.
+type sessionCipher struct {
+	seal Cipher
+	open Cipher
+}
+
+func (h *handshake) newSessionCipher(sealKey, openKey []byte) (c Cipher, err error) {
+	var s sessionCipher
+
+	if s.seal, err = h.cfg.NewCipher(sealKey); err != nil {
+		return
+	}
+	if s.open, err = h.cfg.NewCipher(openKey); err != nil {
+		return
+	}
+
+	return s, nil
+}
+
+func (s sessionCipher) Encrypt(plaintext []byte) []byte {
+	return s.seal.Encrypt(plaintext)
+}
+
+func (s sessionCipher) Decrypt(ciphertext []byte) []byte {
+	return s.open.Decrypt(ciphertext)
+}
+
+func (s sessionCipher) Seal(plaintext, additionalData []byte) ([]byte, error) {
+	return s.seal.Seal(plaintext, additionalData)
+}
+
+func (s sessionCipher) Open(ciphertext, additionalData []byte) ([]byte, error) {
+	return s.open.Open(ciphertext, additionalData)
+}
+
+// ClientHandshake is the client side of a session key exchange.
+type ClientHandshake struct {
+	handshake
+	priv *ecdh.PrivateKey
+}
+
+// ServerHandshake is the server side of a session key exchange.
+type ServerHandshake struct {
+	handshake
+}
+
+func checkHandshakeConfig(cfg HandshakeConfig) error {
+	if cfg.KeySize < 1 || cfg.NewCipher == nil {
+		return errors.New("handshake needs a key size and a cipher constructor")
+	}
+	return nil
+}
+
+// NewClientHandshake starts a handshake and returns the client hello to send.
+func NewClientHandshake(cfg HandshakeConfig) (h *ClientHandshake, hello []byte, err error) {
+	var priv *ecdh.PrivateKey
+
+	if err = checkHandshakeConfig(cfg); err != nil {
+		return
+	}
+	if priv, err = ecdh.X25519().GenerateKey(rand.Reader); err != nil {
+		err = errors.Wrapf(err, "generating X25519 key failed")
+		return
+	}
+
+	h = &ClientHandshake{handshake: handshake{cfg: cfg}, priv: priv}
+	hello = priv.PublicKey().Bytes()
+
+	return
+}
+
+// Finish checks the server hello and returns the session cipher and the
+// client finished message to send. The cipher seals with the client write key
+// and opens with the server write key.
+func (h *ClientHandshake) Finish(serverHello []byte) (c Cipher, finished []byte, err error) {
+	var (
+		pub    *ecdh.PublicKey
+		secret []byte
+	)
+
+	if len(serverHello) != 64 {
+		err = errors.Errorf("server hello must be 64 bytes, got %d", len(serverHello))
+		return
+	}
+	if pub, err = ecdh.X25519().NewPublicKey(serverHello[:32]); err != nil {
+		err = errors.Wrapf(err, "invalid server public key")
+		return
+	}
+	if secret, err = h.priv.ECDH(pub); err != nil {
+		err = errors.Wrapf(err, "X25519 failed")
+		return
+	}
+	if err = h.derive(secret, h.priv.PublicKey().Bytes(), serverHello[:32]); err != nil {
+		return
+	}
+	if !hmac.Equal(serverHello[32:], h.serverMAC) {
+		err = errors.New("server finished does not match the transcript")
+		return
+	}
+	if c, err = h.newSessionCipher(h.clientKey, h.serverKey); err != nil {
+		return
+	}
+
+	return c, h.clientMAC, nil
+}
+
+// NewServerHandshake prepares the server side for one client.
+func NewServerHandshake(cfg HandshakeConfig) (h *ServerHandshake, err error) {
+	if err = checkHandshakeConfig(cfg); err != nil {
+		return
+	}
+	return &ServerHandshake{handshake{cfg: cfg}}, nil
+}
+
+// Respond takes the client hello and returns the server hello to send.
+func (h *ServerHandshake) Respond(clientHello []byte) (serverHello []byte, err error) {
+	var (
+		priv   *ecdh.PrivateKey
+		pub    *ecdh.PublicKey
+		secret []byte
+	)
+
+	if pub, err = ecdh.X25519().NewPublicKey(clientHello); err != nil {
+		err = errors.Wrapf(err, "invalid client public key")
+		return
+	}
+	if priv, err = ecdh.X25519().GenerateKey(rand.Reader); err != nil {
+		err = errors.Wrapf(err, "generating X25519 key failed")
+		return
+	}
+	if secret, err = priv.ECDH(pub); err != nil {
+		err = errors.Wrapf(err, "X25519 failed")
+		return
+	}
+	if err = h.derive(secret, clientHello, priv.PublicKey().Bytes()); err != nil {
+		return
+	}
+
+	return append(priv.PublicKey().Bytes(), h.serverMAC...), nil
+}
+
+// Finish checks the client finished message and returns the session cipher,
+// which seals with the server write key and opens with the client write key.
+func (h *ServerHandshake) Finish(clientFinished []byte) (c Cipher, err error) {
+	if h.clientMAC == nil {
+		err = errors.New("Finish called before Respond")
+		return
+	}
+	if !hmac.Equal(clientFinished, h.clientMAC) {
+		err = errors.New("client finished does not match the transcript")
+		return
+	}
+
+	return h.newSessionCipher(h.serverKey, h.clientKey)
+}
@@ -0,0 +1,139 @@
+package crypto
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestHandshake(t *testing.T) {
+	var (
+		cfg = HandshakeConfig{
+			KeySize: 16,
+			NewCipher: func(key []byte) (Cipher, error) {
+				return NewAes(key, GCM, nil, PAD_PKCS5)
+			},
+		}
+		flip = func(b []byte, i int) []byte {
+			b = append([]byte{}, b...)
+			b[i] ^= 1
+			return b
+		}
+	)
+
+	Convey("TEST Handshake", t, func() {
+		client, hello, err := NewClientHandshake(cfg)
+		So(err, ShouldBeNil)
+		So(hello, ShouldHaveLength, 32)
+
+		server, err := NewServerHandshake(cfg)
+		So(err, ShouldBeNil)
+		serverHello, err := server.Respond(hello)
+		So(err, ShouldBeNil)
+		So(serverHello, ShouldHaveLength, 64)
+
+		cc, finished, err := client.Finish(serverHello)
+		So(err, ShouldBeNil)
+		sc, err := server.Finish(finished)
+		So(err, ShouldBeNil)
+
+		So(sc.Decrypt(cc.Encrypt([]byte("from client"))), ShouldResemble, []byte("from client"))
+		So(cc.Decrypt(sc.Encrypt([]byte("from server"))), ShouldResemble, []byte("from server"))
+
+		// Each direction has its own key: a message cannot be reflected to its sender
+		So(cc.Decrypt(cc.Encrypt([]byte("from client"))), ShouldBeNil)
+		So(sc.Decrypt(sc.Encrypt([]byte("from server"))), ShouldBeNil)
+
+		// Each handshake negotiates a new key
+		other, hello2, _ := NewClientHandshake(cfg)
+		server2, _ := NewServerHandshake(cfg)
+		serverHello2, _ := server2.Respond(hello2)
+		oc, _, err := other.Finish(serverHello2)
+		So(err, ShouldBeNil)
+		So(oc.Encrypt([]byte("x")), ShouldNotResemble, cc.Encrypt([]byte("x")))
+	})
+
+	Convey("TEST Handshake detects tampering", t, func() {
+		// A changed client public key
+		client, hello, _ := NewClientHandshake(cfg)
+		server, _ := NewServerHandshake(cfg)
+		serverHello, err := server.Respond(flip(hello, 3))
+		So(err, ShouldBeNil)
+		_, _, err = client.Finish(serverHello)
+		So(err, ShouldNotBeNil)
+
+		// A changed server public key or server finished
+		for _, i := range []int{5, 40} {
+			client, hello, _ = NewClientHandshake(cfg)
+			server, _ = NewServerHandshake(cfg)
+			serverHello, _ = server.Respond(hello)
+			_, _, err = client.Finish(flip(serverHello, i))
+			So(err, ShouldNotBeNil)
+		}
+
+		// A changed client finished
+		client, hello, _ = NewClientHandshake(cfg)
+		server, _ = NewServerHandshake(cfg)
+		serverHello, _ = server.Respond(hello)
+		_, finished, _ := client.Finish(serverHello)
+		_, err = server.Finish(flip(finished, 0))
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("TEST Handshake with a PSK", t, func() {
+		psk := cfg
+		psk.PSK = []byte("shared in advance")
+
+		// relay runs one handshake with the client and one with the server
+		relay := func(clientCfg, relayCfg, serverCfg HandshakeConfig) (clientErr, serverErr error) {
+			client, hello, _ := NewClientHandshake(clientCfg)
+			toClient, _ := NewServerHandshake(relayCfg)
+			toServer, relayHello, _ := NewClientHandshake(relayCfg)
+			server, _ := NewServerHandshake(serverCfg)
+
+			serverHello, _ := server.Respond(relayHello)
+			_, relayFinished, _ := toServer.Finish(serverHello)
+			_, serverErr = server.Finish(relayFinished)
+
+			relayServerHello, _ := toClient.Respond(hello)
+			_, _, clientErr = client.Finish(relayServerHello)
+			return
+		}
+
+		// Without a PSK nothing stops a man in the middle
+		clientErr, serverErr := relay(cfg, cfg, cfg)
+		So(clientErr, ShouldBeNil)
+		So(serverErr, ShouldBeNil)
+
+		clientErr, serverErr = relay(psk, cfg, psk)
+		So(clientErr, ShouldNotBeNil)
+		So(serverErr, ShouldNotBeNil)
+
+		client, hello, _ := NewClientHandshake(psk)
+		server, _ := NewServerHandshake(psk)
+		serverHello, _ := server.Respond(hello)
+		cc, finished, err := client.Finish(serverHello)
+		So(err, ShouldBeNil)
+		sc, err := server.Finish(finished)
+		So(err, ShouldBeNil)
+		So(sc.Decrypt(cc.Encrypt([]byte("from client"))), ShouldResemble, []byte("from client"))
+	})
+
+	Convey("TEST Handshake errors", t, func() {
+		_, _, err := NewClientHandshake(HandshakeConfig{})
+		So(err, ShouldNotBeNil)
+
+		server, _ := NewServerHandshake(cfg)
+		_, err = server.Finish(make([]byte, 32))
+		So(err, ShouldNotBeNil)
+		_, err = server.Respond(make([]byte, 31))
+		So(err, ShouldNotBeNil)
+		// Low-order point: the shared secret is all zeros
+		_, err = server.Respond(make([]byte, 32))
+		So(err, ShouldNotBeNil)
+
+		client, _, _ := NewClientHandshake(cfg)
+		_, _, err = client.Finish(make([]byte, 63))
+		So(err, ShouldNotBeNil)
+	})
+}
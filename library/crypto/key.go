@@ -0,0 +1,106 @@
+package crypto
+
+import (
+	"crypto/hkdf"
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"math/bits"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrWeakKey is returned by CheckDesKey for DES weak and semi-weak keys.
+var ErrWeakKey = errors.New("weak or semi-weak DES key")
+
+// weakDesKeys are the 4 weak and 12 semi-weak DES keys, with odd parity.
+var weakDesKeys = []string{
+	"0101010101010101", "fefefefefefefefe", "e0e0e0e0f1f1f1f1", "1f1f1f1f0e0e0e0e",
+	"01fe01fe01fe01fe", "fe01fe01fe01fe01", "1fe01fe00ef10ef1", "e01fe01ff10ef10e",
+	"01e001e001f101f1", "e001e001f101f101", "1ffe1ffe0efe0efe", "fe1ffe1ffe0efe0e",
+	"011f011f010e010e", "1f011f010e010e01", "e0fee0fef1fef1fe", "fee0fee0fef1fef1",
+}
+
+// PBKDF2Key derives a keySize-byte key from a passphrase with PBKDF2-HMAC-SHA256.
+// The salt should be random per key and iterations as high as the scenario
+// allows.
+func PBKDF2Key(passphrase string, salt []byte, iterations, keySize int) (key []byte, err error) {
+	if iterations < 1 {
+		err = errors.Errorf("PBKDF2 needs at least one iteration, got %d", iterations)
+		return
+	}
+	if key, err = pbkdf2.Key(sha256.New, passphrase, salt, iterations, keySize); err != nil {
+		err = errors.Wrapf(err, "pbkdf2.Key failed")
+	}
+	return
+}
+
+// HKDFKey derives a keySize-byte key from high-entropy secret material with
+// HKDF-SHA256; info separates keys derived from the same secret.
+func HKDFKey(secret, salt []byte, info string, keySize int) (key []byte, err error) {
+	if key, err = hkdf.Key(sha256.New, secret, salt, info, keySize); err != nil {
+		err = errors.Wrapf(err, "hkdf.Key failed")
+	}
+	return
+}
+
+// ParseHexKey decodes a hex key, upper or lower case, ignoring spaces and colons.
+func ParseHexKey(s string) (key []byte, err error) {
+	s = strings.NewReplacer(" ", "", ":", "").Replace(s)
+	if key, err = hex.DecodeString(s); err != nil {
+		err = errors.Wrapf(err, "invalid hex key")
+	}
+	return
+}
+
+// ParseBase64Key decodes a standard or URL-safe base64 key, with or without padding.
+func ParseBase64Key(s string) (key []byte, err error) {
+	s = strings.TrimRight(strings.TrimSpace(s), "=")
+	if strings.ContainsAny(s, "-_") {
+		key, err = base64.RawURLEncoding.DecodeString(s)
+	} else {
+		key, err = base64.RawStdEncoding.DecodeString(s)
+	}
+	if err != nil {
+		err = errors.Wrapf(err, "invalid base64 key")
+	}
+	return
+}
+
+// SetDesParity returns a copy of key with the low bit of every byte set so
+// that the byte has odd parity, as DES keys are specified.
+func SetDesParity(key []byte) []byte {
+	out := make([]byte, len(key))
+	for i, b := range key {
+		b &^= 1
+		if bits.OnesCount8(b)%2 == 0 {
+			b |= 1
+		}
+		out[i] = b
+	}
+	return out
+}
+
+// CheckDesKey checks that key is a DES (8 bytes) or Triple DES (16 or 24
+// bytes) key and that none of its DES keys is weak or semi-weak, ignoring
+// parity bits. It returns ErrWeakKey for those.
+func CheckDesKey(key []byte) error {
+	switch len(key) {
+	case 8, 16, 24:
+	default:
+		return errors.Errorf("DES key must be 8, 16 or 24 bytes, got %d", len(key))
+	}
+
+	for i := 0; i < len(key); i += 8 {
+		k := hex.EncodeToString(SetDesParity(key[i : i+8]))
+		for _, w := range weakDesKeys {
+			if k == w {
+				return errors.Wrapf(ErrWeakKey, "key %d", i/8+1)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package crypto
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/pkg/errors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestKeyDerivation(t *testing.T) {
+	Convey("TEST PBKDF2Key", t, func() {
+		type Case struct {
+			iterations int
+			key        string
+		}
+
+		// PBKDF2-HMAC-SHA256 with P = "password", S = "salt"
+		for _, c := range []Case{
+			{1, "120fb6cffcf8b32c43e7225256c4f837a86548c92ccc35480805987cb70be17b"},
+			{2, "ae4d0c95af6b46d32d0adff928f06dd02a303f8ef3c251dfd6e2d85a95474c43"},
+			{4096, "c5e478d59288c841aa530db6845c4c8d962893a001ce4e11a4963873aa98134a"},
+		} {
+			key, err := PBKDF2Key("password", []byte("salt"), c.iterations, 32)
+			So(err, ShouldBeNil)
+			So(hex.EncodeToString(key), ShouldEqual, c.key)
+		}
+
+		key, _ := PBKDF2Key("password", []byte("salt"), 1, 8)
+		So(hex.EncodeToString(key), ShouldEqual, "120fb6cffcf8b32c")
+
+		_, err := PBKDF2Key("password", []byte("salt"), 0, 8)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("TEST HKDFKey", t, func() {
+		// RFC 5869 test case 1
+		secret, _ := hex.DecodeString("0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b")
+		salt, _ := hex.DecodeString("000102030405060708090a0b0c")
+		info, _ := hex.DecodeString("f0f1f2f3f4f5f6f7f8f9")
+
+		key, err := HKDFKey(secret, salt, string(info), 42)
+		So(err, ShouldBeNil)
+		So(hex.EncodeToString(key), ShouldEqual, "3cb25f25faacd57a90434f64d0362f2a2d2d0a90cf1a5a4c5db02d56ecc4c5bf34007208d5b887185865")
+	})
+}
+
+func TestParseKey(t *testing.T) {
+	Convey("TEST ParseHexKey", t, func() {
+		for _, s := range []string{"0123456789abcdef", "0123456789ABCDEF", "01 23 45 67 89 AB CD EF", "01:23:45:67:89:ab:cd:ef"} {
+			key, err := ParseHexKey(s)
+			So(err, ShouldBeNil)
+			So(key, ShouldResemble, []byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef})
+		}
+		for _, s := range []string{"0123456789abcde", "0123456789abcdeg"} {
+			_, err := ParseHexKey(s)
+			So(err, ShouldNotBeNil)
+		}
+	})
+
+	Convey("TEST ParseBase64Key", t, func() {
+		for _, s := range []string{"+/8=", "+/8", "-_8=", "-_8"} {
+			key, err := ParseBase64Key(s)
+			So(err, ShouldBeNil)
+			So(key, ShouldResemble, []byte{0xfb, 0xff})
+		}
+		_, err := ParseBase64Key("not base64!")
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestDesKey(t *testing.T) {
+	Convey("TEST SetDesParity", t, func() {
+		key := SetDesParity([]byte{0x00, 0x01, 0x02, 0x03, 0xfe, 0xff, 0x80, 0x81})
+		So(key, ShouldResemble, []byte{0x01, 0x01, 0x02, 0x02, 0xfe, 0xfe, 0x80, 0x80})
+	})
+
+	Convey("TEST CheckDesKey", t, func() {
+		So(CheckDesKey([]byte("TANGTANG")), ShouldBeNil)
+		So(CheckDesKey(make([]byte, 7)), ShouldNotBeNil)
+		So(CheckDesKey(make([]byte, 9)), ShouldNotBeNil)
+
+		for _, w := range weakDesKeys {
+			key, _ := hex.DecodeString(w)
+			So(errors.Cause(CheckDesKey(key)), ShouldEqual, ErrWeakKey)
+		}
+
+		// Parity bits are ignored, and each 3DES key is checked
+		So(errors.Cause(CheckDesKey(make([]byte, 8))), ShouldEqual, ErrWeakKey)
+		key, _ := hex.DecodeString("0123456789abcdef1f1f1f1f0e0e0e0f")
+		So(errors.Cause(CheckDesKey(key)), ShouldEqual, ErrWeakKey)
+		key, _ = hex.DecodeString("0123456789abcdef23456789abcdef01456789abcdef0123")
+		So(CheckDesKey(key), ShouldBeNil)
+	})
+}
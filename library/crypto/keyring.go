@@ -0,0 +1,190 @@
+package crypto
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Keyring holds keys with IDs and validity windows for key rotation. Seal
+// uses the active key and puts its ID in front of the ciphertext: one length
+// byte and the ID. Open picks the key by that ID, or tries every valid key
+// when the message has no known ID or Untagged is set. Trial decryption is
+// only dependable with authenticated ciphers (GCM, Envelope); padding checks
+// alone let a wrong key through about once in 256 messages.
+//
+// The zero Keyring is empty and ready to use; it is safe for concurrent use.
+type Keyring struct {
+	// Untagged leaves the ID out of Seal's output and makes Open try all keys.
+	Untagged bool
+	// Now is the clock for validity windows, time.Now if nil.
+	Now func() time.Time
+
+	mu   sync.RWMutex
+	keys []ringKey
+}
+
+type ringKey struct {
+	id        string
+	c         Cipher
+	notBefore time.Time
+	notAfter  time.Time
+}
+
+var _ Cipher = (*Keyring)(nil)
+
+// valid reports whether t is in the key's window; zero bounds are open.
+func (k ringKey) valid(t time.Time) bool {
+	return (k.notBefore.IsZero() || !t.Before(k.notBefore)) && (k.notAfter.IsZero() || t.Before(k.notAfter))
+}
+
+func (r *Keyring) now() time.Time {
+	if r.Now != nil {
+		return r.Now()
+	}
+	return time.Now()
+}
+
+// Add adds c under id, valid from notBefore until notAfter; a zero time
+// leaves that side open. id is 1 to 255 bytes and unique in the Keyring.
+func (r *Keyring) Add(id string, c Cipher, notBefore, notAfter time.Time) error {
+	if len(id) == 0 || len(id) > 255 {
+		return errors.Errorf("key ID must be 1 to 255 bytes, got %d", len(id))
+	}
+	if !notAfter.IsZero() && !notAfter.After(notBefore) {
+		return errors.Errorf("key %q expires before it is valid", id)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, k := range r.keys {
+		if k.id == id {
+			return errors.Errorf("duplicate key ID %q", id)
+		}
+	}
+	r.keys = append(r.keys, ringKey{id: id, c: c, notBefore: notBefore, notAfter: notAfter})
+
+	return nil
+}
+
+// Remove drops the key with id, if any.
+func (r *Keyring) Remove(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, k := range r.keys {
+		if k.id == id {
+			r.keys = append(r.keys[:i], r.keys[i+1:]...)
+			return
+		}
+	}
+}
+
+// Active returns the ID of the key Seal uses now: of the valid keys, the one
+// that became valid last, or was added last among equals.
+func (r *Keyring) Active() (id string, err error) {
+	var k ringKey
+
+	if k, err = r.active(); err == nil {
+		id = k.id
+	}
+	return
+}
+
+func (r *Keyring) active() (active ringKey, err error) {
+	var (
+		now   = r.now()
+		found bool
+	)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, k := range r.keys {
+		if k.valid(now) && (!found || !k.notBefore.Before(active.notBefore)) {
+			active, found = k, true
+		}
+	}
+	if !found {
+		err = errors.New("no valid key in keyring")
+	}
+
+	return
+}
+
+// valid returns the keys valid now, the one with id first if there is one.
+func (r *Keyring) valid(id string) (keys []ringKey, tagged bool) {
+	now := r.now()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, k := range r.keys {
+		if !k.valid(now) {
+			continue
+		}
+		if k.id == id {
+			return []ringKey{k}, true
+		}
+		keys = append(keys, k)
+	}
+
+	return
+}
+
+func (r *Keyring) Encrypt(plaintext []byte) (ciphertext []byte) {
+	ciphertext, _ = r.Seal(plaintext, nil)
+	return
+}
+
+func (r *Keyring) Decrypt(ciphertext []byte) (plaintext []byte) {
+	plaintext, _ = r.Open(ciphertext, nil)
+	return
+}
+
+// Seal encrypts with the active key, tagging the output with its ID.
+func (r *Keyring) Seal(plaintext, additionalData []byte) (ciphertext []byte, err error) {
+	var k ringKey
+
+	if k, err = r.active(); err != nil {
+		return
+	}
+	if ciphertext, err = k.c.Seal(plaintext, additionalData); err != nil || r.Untagged {
+		return
+	}
+
+	return append(append([]byte{byte(len(k.id))}, k.id...), ciphertext...), nil
+}
+
+// Open decrypts with the key named by the ID tag, or by trial. Expired and
+// not yet valid keys are not used.
+func (r *Keyring) Open(ciphertext, additionalData []byte) (plaintext []byte, err error) {
+	var (
+		id     string
+		body   = ciphertext
+		keys   []ringKey
+		tagged bool
+	)
+
+	if !r.Untagged && len(ciphertext) > 0 && len(ciphertext) > int(ciphertext[0]) {
+		id, body = string(ciphertext[1:1+ciphertext[0]]), ciphertext[1+ciphertext[0]:]
+	}
+
+	if keys, tagged = r.valid(id); tagged {
+		return keys[0].c.Open(body, additionalData)
+	}
+	if len(keys) == 0 {
+		err = errors.New("no valid key in keyring")
+		return
+	}
+
+	for _, k := range keys {
+		if plaintext, err = k.c.Open(ciphertext, additionalData); err == nil {
+			return
+		}
+	}
+
+	return nil, errors.New("no key in keyring decrypts the message")
+}
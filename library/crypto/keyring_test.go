@@ -0,0 +1,88 @@
+package crypto
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestKeyring(t *testing.T) {
+	var (
+		t0    = time.Date(2018, 8, 1, 0, 0, 0, 0, time.UTC)
+		now   = t0
+		clock = func() time.Time { return now }
+		gcm   = func(key string) Cipher {
+			c, _ := NewAes([]byte(key), GCM, nil, PAD_PKCS5)
+			return c
+		}
+		oldKey = gcm("old key 16 bytes")
+		newKey = gcm("new key 16 bytes")
+	)
+
+	Convey("TEST Keyring rotation", t, func() {
+		now = t0
+		r := &Keyring{Now: clock}
+		So(r.Add("k1", oldKey, time.Time{}, t0.Add(2*time.Hour)), ShouldBeNil)
+		So(r.Add("k2", newKey, t0.Add(time.Hour), time.Time{}), ShouldBeNil)
+
+		id, err := r.Active()
+		So(err, ShouldBeNil)
+		So(id, ShouldEqual, "k1")
+
+		before := r.Encrypt([]byte("payload"))
+		So(before[:3], ShouldResemble, []byte("\x02k1"))
+		So(oldKey.Decrypt(before[3:]), ShouldResemble, []byte("payload"))
+
+		// Rollover: the new key encrypts, both decrypt
+		now = t0.Add(90 * time.Minute)
+		id, _ = r.Active()
+		So(id, ShouldEqual, "k2")
+		after := r.Encrypt([]byte("payload"))
+		So(after[:3], ShouldResemble, []byte("\x02k2"))
+		So(r.Decrypt(before), ShouldResemble, []byte("payload"))
+		So(r.Decrypt(after), ShouldResemble, []byte("payload"))
+
+		// The old key has expired
+		now = t0.Add(3 * time.Hour)
+		_, err = r.Open(before, nil)
+		So(err, ShouldNotBeNil)
+		So(r.Decrypt(after), ShouldResemble, []byte("payload"))
+
+		r.Remove("k2")
+		_, err = r.Active()
+		So(err, ShouldNotBeNil)
+		_, err = r.Seal([]byte("payload"), nil)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("TEST Keyring trial decryption", t, func() {
+		now = t0
+		r := &Keyring{Now: clock}
+		_ = r.Add("k1", oldKey, time.Time{}, time.Time{})
+		_ = r.Add("k2", newKey, time.Time{}, time.Time{})
+
+		// Untagged messages from a peer are tried against every key
+		So(r.Decrypt(oldKey.Encrypt([]byte("from k1"))), ShouldResemble, []byte("from k1"))
+		So(r.Decrypt(newKey.Encrypt([]byte("from k2"))), ShouldResemble, []byte("from k2"))
+		So(r.Decrypt(gcm("unknown key 16by").Encrypt([]byte("x"))), ShouldBeNil)
+
+		u := &Keyring{Untagged: true, Now: clock}
+		_ = u.Add("k1", oldKey, time.Time{}, time.Time{})
+		_ = u.Add("k2", newKey, time.Time{}, time.Time{})
+		So(newKey.Decrypt(u.Encrypt([]byte("payload"))), ShouldResemble, []byte("payload"))
+		So(u.Decrypt(oldKey.Encrypt([]byte("payload"))), ShouldResemble, []byte("payload"))
+	})
+
+	Convey("TEST Keyring errors", t, func() {
+		r := &Keyring{}
+		So(r.Add("", oldKey, time.Time{}, time.Time{}), ShouldNotBeNil)
+		So(r.Add(string(make([]byte, 256)), oldKey, time.Time{}, time.Time{}), ShouldNotBeNil)
+		So(r.Add("k1", oldKey, t0, t0), ShouldNotBeNil)
+		So(r.Add("k1", oldKey, time.Time{}, time.Time{}), ShouldBeNil)
+		So(r.Add("k1", newKey, time.Time{}, time.Time{}), ShouldNotBeNil)
+
+		_, err := (&Keyring{}).Open([]byte("\x02k1"), nil)
+		So(err, ShouldNotBeNil)
+	})
+}
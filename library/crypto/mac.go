@@ -0,0 +1,136 @@
+package crypto
+
+import (
+	"crypto/cipher"
+	"crypto/des"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// ISO/IEC 9797-1 padding methods
+	MAC_PAD_METHOD1 = 1 // zero bytes up to a whole block; empty data becomes one block
+	MAC_PAD_METHOD2 = 2 // 0x80 and then zero bytes, always added
+)
+
+// macPad pads msg with ISO/IEC 9797-1 padding method 1 or 2.
+func macPad(msg []byte, blockSize int, padMethod int) ([]byte, error) {
+	switch padMethod {
+	case MAC_PAD_METHOD1:
+		if len(msg) > 0 && len(msg)%blockSize == 0 {
+			return clone(msg), nil
+		}
+		return ZeroPad.Pad(msg, blockSize)
+	case MAC_PAD_METHOD2:
+		return ISO7816.Pad(msg, blockSize)
+	}
+	return nil, errors.Errorf("unsupported MAC padding method %d", padMethod)
+}
+
+// cbcMAC returns the last block of the CBC encryption of data, a whole
+// number of blocks, with a zero IV.
+func cbcMAC(b cipher.Block, data []byte) []byte {
+	out := make([]byte, len(data))
+	cipher.NewCBCEncrypter(b, make([]byte, b.BlockSize())).CryptBlocks(out, data)
+	return out[len(out)-b.BlockSize():]
+}
+
+// MACAlg1 is ISO/IEC 9797-1 MAC algorithm 1, the CBC-MAC of ANSI X9.9 when b
+// is DES. The MAC is a whole block; truncate it as the protocol requires.
+func MACAlg1(b cipher.Block, msg []byte, padMethod int) (mac []byte, err error) {
+	var data []byte
+
+	if data, err = macPad(msg, b.BlockSize(), padMethod); err != nil {
+		return
+	}
+
+	return cbcMAC(b, data), nil
+}
+
+// MACAlg3 is ISO/IEC 9797-1 MAC algorithm 3, the ANSI X9.19 "retail MAC":
+// a single-DES CBC-MAC under K whose last block is then decrypted under K'
+// and encrypted under K again. key is K followed by K', 16 bytes.
+func MACAlg3(key []byte, msg []byte, padMethod int) (mac []byte, err error) {
+	var (
+		k, k2 cipher.Block
+		data  []byte
+	)
+
+	if len(key) != 16 {
+		err = errors.Errorf("retail MAC key must be 16 bytes, got %d", len(key))
+		return
+	}
+	if k, err = des.NewCipher(key[:8]); err != nil {
+		err = errors.Wrapf(err, "des.NewCipher failed")
+		return
+	}
+	if k2, err = des.NewCipher(key[8:]); err != nil {
+		err = errors.Wrapf(err, "des.NewCipher failed")
+		return
+	}
+	if data, err = macPad(msg, des.BlockSize, padMethod); err != nil {
+		return
+	}
+
+	mac = cbcMAC(k, data)
+	k2.Decrypt(mac, mac)
+	k.Encrypt(mac, mac)
+
+	return
+}
+
+// CMAC is the NIST SP 800-38B cipher-based MAC over DES, 3DES (64-bit
+// blocks) or AES (128-bit blocks).
+func CMAC(b cipher.Block, msg []byte) (mac []byte, err error) {
+	var (
+		bs     = b.BlockSize()
+		rb     byte
+		k1, k2 []byte
+		data   []byte
+	)
+
+	switch bs {
+	case 8:
+		rb = 0x1b
+	case 16:
+		rb = 0x87
+	default:
+		err = errors.Errorf("CMAC needs a 64 or 128-bit block, got %d bytes", bs)
+		return
+	}
+
+	// Subkeys: L = E(0), K1 = L·x, K2 = K1·x in GF(2^n)
+	k1 = make([]byte, bs)
+	b.Encrypt(k1, k1)
+	k1 = dbl(k1, rb)
+	k2 = dbl(k1, rb)
+
+	if len(msg) > 0 && len(msg)%bs == 0 {
+		data = clone(msg)
+		xorBytes(data[len(data)-bs:], k1)
+	} else {
+		data, _ = ISO7816.Pad(msg, bs)
+		xorBytes(data[len(data)-bs:], k2)
+	}
+
+	return cbcMAC(b, data), nil
+}
+
+// dbl multiplies the block v by x in GF(2^n) reduced by rb.
+func dbl(v []byte, rb byte) []byte {
+	out := make([]byte, len(v))
+	for i := 0; i < len(v)-1; i++ {
+		out[i] = v[i]<<1 | v[i+1]>>7
+	}
+	out[len(v)-1] = v[len(v)-1] << 1
+	if v[0]&0x80 != 0 {
+		out[len(v)-1] ^= rb
+	}
+	return out
+}
+
+func xorBytes(dst, src []byte) {
+	for i := range src {
+		dst[i] ^= src[i]
+	}
+}
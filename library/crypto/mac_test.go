@@ -0,0 +1,105 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"encoding/hex"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMAC(t *testing.T) {
+	type Case struct {
+		key       string
+		msg       string
+		padMethod int
+		alg1      string
+		alg3      string
+	}
+
+	var (
+		now   = "4e6f77206973207468652074696d6520666f7220616c6c20" // "Now is the time for all "
+		cases = []Case{
+			// ANSI X9.9 and X9.19 examples, the rest cross-checked with OpenSSL
+			{"0123456789abcdeffedcba9876543210", now, MAC_PAD_METHOD1, "70a30640cc76dd8b", "a1c72e74ea3fa9b6"},
+			{"0123456789abcdeffedcba9876543210", now, MAC_PAD_METHOD2, "10e1f0f108341b6d", "e9086230ca3be796"},
+			{"0123456789abcdeffedcba9876543210", "48656c6c6f", MAC_PAD_METHOD1, "976f7ffea942676c", "be8f38eecdab2d35"},
+			{"0123456789abcdeffedcba9876543210", "48656c6c6f", MAC_PAD_METHOD2, "1d4c76fe0cac9913", "8f9e4ccdd623e590"},
+			{"0123456789abcdeffedcba9876543210", "", MAC_PAD_METHOD1, "d5d44ff720683d0d", "08d7b4fb629d0885"},
+			{"0123456789abcdeffedcba9876543210", "", MAC_PAD_METHOD2, "caee534c523e1e79", "f1fbcf2a56d19ba7"},
+		}
+	)
+
+	Convey("TEST ISO 9797-1 MAC algorithms 1 and 3", t, func() {
+		for _, c := range cases {
+			key, _ := hex.DecodeString(c.key)
+			msg, _ := hex.DecodeString(c.msg)
+			b, _ := des.NewCipher(key[:8])
+
+			mac, err := MACAlg1(b, msg, c.padMethod)
+			So(err, ShouldBeNil)
+			So(hex.EncodeToString(mac), ShouldEqual, c.alg1)
+
+			mac, err = MACAlg3(key, msg, c.padMethod)
+			So(err, ShouldBeNil)
+			So(hex.EncodeToString(mac), ShouldEqual, c.alg3)
+		}
+	})
+
+	Convey("TEST ISO 9797-1 errors", t, func() {
+		b, _ := des.NewCipher([]byte("TANGTANG"))
+		_, err := MACAlg1(b, nil, 3)
+		So(err, ShouldNotBeNil)
+		_, err = MACAlg3(make([]byte, 8), nil, MAC_PAD_METHOD1)
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestCMAC(t *testing.T) {
+	type Case struct {
+		key  string
+		n    int
+		cmac string
+	}
+
+	var (
+		msg   = "6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e5130c81c46a35ce411e5fbc1191a0a52eff69f2445df4f9b17ad2b417be66c3710"
+		cases = []Case{
+			// NIST SP 800-38B appendix D.1 AES-128
+			{"2b7e151628aed2a6abf7158809cf4f3c", 0, "bb1d6929e95937287fa37d129b756746"},
+			{"2b7e151628aed2a6abf7158809cf4f3c", 16, "070a16b46b4d4144f79bdd9dd04a287c"},
+			{"2b7e151628aed2a6abf7158809cf4f3c", 40, "dfa66747de9ae63030ca32611497c827"},
+			{"2b7e151628aed2a6abf7158809cf4f3c", 64, "51f0bebf7e3b9d92fc49741779363cfe"},
+			// D.4 three-key TDEA
+			{"8aa83bf8cbda10620bc1bf19fbb6cd58bc313d4a371ca8b5", 0, "b7a688e122ffaf95"},
+			{"8aa83bf8cbda10620bc1bf19fbb6cd58bc313d4a371ca8b5", 8, "8e8f293136283797"},
+			{"8aa83bf8cbda10620bc1bf19fbb6cd58bc313d4a371ca8b5", 20, "743ddbe0ce2dc2ed"},
+			{"8aa83bf8cbda10620bc1bf19fbb6cd58bc313d4a371ca8b5", 32, "33e6b1092400eae5"},
+			// D.5 two-key TDEA
+			{"4cf15134a2850dd58a3d10ba80570d384cf15134a2850dd5", 0, "bd2ebf9a3ba00361"},
+			{"4cf15134a2850dd58a3d10ba80570d384cf15134a2850dd5", 8, "4ff2ab813c53ce83"},
+			{"4cf15134a2850dd58a3d10ba80570d384cf15134a2850dd5", 20, "62dd1b471902bd4e"},
+			{"4cf15134a2850dd58a3d10ba80570d384cf15134a2850dd5", 32, "31b1e431dabc4eb8"},
+		}
+	)
+
+	Convey("TEST CMAC", t, func() {
+		for _, c := range cases {
+			var b cipher.Block
+
+			key, _ := hex.DecodeString(c.key)
+			m, _ := hex.DecodeString(msg)
+			if len(key) == 16 {
+				b, _ = aes.NewCipher(key)
+			} else {
+				b, _ = des.NewTripleDESCipher(key)
+			}
+
+			mac, err := CMAC(b, m[:c.n])
+			So(err, ShouldBeNil)
+			So(hex.EncodeToString(mac), ShouldEqual, c.cmac)
+		}
+	})
+}
@@ -0,0 +1,100 @@
+package crypto
+
+import (
+	"crypto/cipher"
+	"crypto/rc4"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// StreamCipher is a keystream cipher for traffic obfuscation with one state
+// per direction: Encrypt continues the outgoing keystream and Decrypt the
+// incoming one, so messages must be processed in the order they are sent.
+// Use one StreamCipher per connection. It offers no integrity and RC4 is
+// broken; it is meant for talking to servers that use it.
+type StreamCipher struct {
+	mu  sync.Mutex
+	enc cipher.Stream
+	dec cipher.Stream
+}
+
+var _ Cipher = (*StreamCipher)(nil)
+
+// NewRC4 keys the outgoing direction with encKey and the incoming one with
+// decKey, or with encKey too if decKey is nil.
+func NewRC4(encKey, decKey []byte) (s *StreamCipher, err error) {
+	var enc, dec *rc4.Cipher
+
+	if decKey == nil {
+		decKey = encKey
+	}
+	if enc, err = rc4.NewCipher(encKey); err != nil {
+		err = errors.Wrapf(err, "rc4.NewCipher failed")
+		return
+	}
+	if dec, err = rc4.NewCipher(decKey); err != nil {
+		err = errors.Wrapf(err, "rc4.NewCipher failed")
+		return
+	}
+
+	return &StreamCipher{enc: enc, dec: dec}, nil
+}
+
+// NewXOR XORs data with the key repeated, the position in the key rolling on
+// from one message to the next. decKey nil means encKey.
+func NewXOR(encKey, decKey []byte) (s *StreamCipher, err error) {
+	if decKey == nil {
+		decKey = encKey
+	}
+	if len(encKey) == 0 || len(decKey) == 0 {
+		err = errors.New("empty XOR key")
+		return
+	}
+
+	return &StreamCipher{enc: &xorStream{key: clone(encKey)}, dec: &xorStream{key: clone(decKey)}}, nil
+}
+
+type xorStream struct {
+	key []byte
+	pos int
+}
+
+func (x *xorStream) XORKeyStream(dst, src []byte) {
+	for i, b := range src {
+		dst[i] = b ^ x.key[x.pos]
+		x.pos = (x.pos + 1) % len(x.key)
+	}
+}
+
+func (s *StreamCipher) Encrypt(plaintext []byte) (ciphertext []byte) {
+	ciphertext, _ = s.Seal(plaintext, nil)
+	return
+}
+
+func (s *StreamCipher) Decrypt(ciphertext []byte) (plaintext []byte) {
+	plaintext, _ = s.Open(ciphertext, nil)
+	return
+}
+
+// Seal continues the outgoing keystream; additionalData is ignored.
+func (s *StreamCipher) Seal(plaintext, additionalData []byte) (ciphertext []byte, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ciphertext = make([]byte, len(plaintext))
+	s.enc.XORKeyStream(ciphertext, plaintext)
+
+	return
+}
+
+// Open continues the incoming keystream; additionalData is ignored.
+func (s *StreamCipher) Open(ciphertext, additionalData []byte) (plaintext []byte, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	plaintext = make([]byte, len(ciphertext))
+	s.dec.XORKeyStream(plaintext, ciphertext)
+
+	return
+}
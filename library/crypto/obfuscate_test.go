@@ -0,0 +1,85 @@
+package crypto
+
+import (
+	"encoding/hex"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRC4(t *testing.T) {
+	type Case struct {
+		key        string
+		plaintext  string
+		ciphertext string
+	}
+
+	var (
+		cases = []Case{
+			{"Key", "Plaintext", "bbf316e8d940af0ad3"},
+			{"Wiki", "pedia", "1021bf0420"},
+			{"Secret", "Attack at dawn", "45a01f645fc35b383552544b9bf5"},
+		}
+	)
+
+	Convey("TEST RC4", t, func() {
+		for _, c := range cases {
+			s, err := NewRC4([]byte(c.key), nil)
+			So(err, ShouldBeNil)
+			So(hex.EncodeToString(s.Encrypt([]byte(c.plaintext))), ShouldEqual, c.ciphertext)
+		}
+
+		// RFC 6229, 40-bit key, first 16 bytes of keystream
+		key, _ := hex.DecodeString("0102030405")
+		s, _ := NewRC4(key, nil)
+		So(hex.EncodeToString(s.Encrypt(make([]byte, 16))), ShouldEqual, "b2396305f03dc027ccc3524a0a1118a8")
+
+		_, err := NewRC4(nil, nil)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("TEST RC4 keystream carries on per direction", t, func() {
+		client, _ := NewRC4([]byte("Key"), []byte("Wiki"))
+		server, _ := NewRC4([]byte("Wiki"), []byte("Key"))
+
+		c1 := client.Encrypt([]byte("Plain"))
+		c2 := client.Encrypt([]byte("text"))
+		So(hex.EncodeToString(append(c1, c2...)), ShouldEqual, "bbf316e8d940af0ad3")
+
+		// Server replies do not disturb the client's outgoing keystream
+		So(hex.EncodeToString(server.Encrypt([]byte("pedia"))), ShouldEqual, "1021bf0420")
+		So(server.Decrypt(c1), ShouldResemble, []byte("Plain"))
+		So(server.Decrypt(c2), ShouldResemble, []byte("text"))
+	})
+}
+
+func TestXOR(t *testing.T) {
+	Convey("TEST XOR rolling key", t, func() {
+		client, err := NewXOR([]byte{0x01, 0x02, 0x03}, nil)
+		So(err, ShouldBeNil)
+		server, _ := NewXOR([]byte{0x01, 0x02, 0x03}, nil)
+
+		So(client.Encrypt([]byte{0, 0}), ShouldResemble, []byte{0x01, 0x02})
+		So(client.Encrypt([]byte{0, 0, 0xff}), ShouldResemble, []byte{0x03, 0x01, 0xfd})
+
+		So(server.Decrypt([]byte{0x01, 0x02}), ShouldResemble, []byte{0, 0})
+		So(server.Decrypt([]byte{0x03, 0x01, 0xfd}), ShouldResemble, []byte{0, 0, 0xff})
+
+		_, err = NewXOR(nil, nil)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("TEST StreamCipher is a Cipher", t, func() {
+		for _, newCipher := range []func() (*StreamCipher, error){
+			func() (*StreamCipher, error) { return NewRC4([]byte("TANGTANG"), nil) },
+			func() (*StreamCipher, error) { return NewXOR([]byte("TANGTANG"), nil) },
+		} {
+			a, _ := newCipher()
+			b, _ := newCipher()
+			var c Cipher = a
+			for _, msg := range []string{"login", "", "move 3 4"} {
+				So(string(b.Decrypt(c.Encrypt([]byte(msg)))), ShouldEqual, msg)
+			}
+		}
+	})
+}
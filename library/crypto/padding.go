@@ -0,0 +1,201 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// Padding fills plaintext up to a whole number of blocks and strips it again.
+// Pad does not modify src.
+type Padding interface {
+	Pad(src []byte, blockSize int) ([]byte, error)
+	Unpad(src []byte, blockSize int) ([]byte, error)
+}
+
+var (
+	// PKCS7 appends n bytes of value n, for any block size up to 255; PKCS5 is
+	// PKCS7 with 8-byte blocks.
+	PKCS7 Padding = pkcs7{}
+	// ISO10126 appends n-1 random bytes and then n.
+	ISO10126 Padding = iso10126{}
+	// ANSIX923 appends n-1 zero bytes and then n.
+	ANSIX923 Padding = ansiX923{}
+	// ISO7816 appends 0x80 and then zero bytes (ISO/IEC 7816-4, ISO 9797-1 method 2).
+	ISO7816 Padding = iso7816{}
+	// ZeroPad appends zero bytes; it cannot tell them from trailing zeros of the message.
+	ZeroPad Padding = zeroPadding{}
+	// NoPadding requires the plaintext to be a whole number of blocks already.
+	NoPadding Padding = noPadding{}
+
+	paddings = map[uint8]Padding{
+		PAD_NORMAL:   ZeroPad,
+		PAD_PKCS5:    PKCS7,
+		PAD_ISO10126: ISO10126,
+		PAD_ANSIX923: ANSIX923,
+		PAD_ISO7816:  ISO7816,
+		PAD_NONE:     NoPadding,
+	}
+)
+
+// pad copies src with n bytes of room and n, the number of padding bytes
+// needed to reach a whole number of blocks (1 to blockSize).
+func pad(src []byte, blockSize int) ([]byte, int, error) {
+	if err := checkBlockSize(blockSize); err != nil {
+		return nil, 0, err
+	}
+	n := blockSize - len(src)%blockSize
+	dst := make([]byte, len(src)+n)
+	copy(dst, src)
+	return dst, n, nil
+}
+
+func checkBlockSize(blockSize int) error {
+	if blockSize < 1 {
+		return errors.Errorf("block size must be positive, got %d", blockSize)
+	}
+	return nil
+}
+
+// checkPadded checks the length of padded data and returns its last byte.
+func checkPadded(src []byte, blockSize int) (int, error) {
+	if err := checkBlockSize(blockSize); err != nil {
+		return 0, err
+	}
+	if len(src) == 0 || len(src)%blockSize != 0 {
+		return 0, errors.Errorf("padded length %d is not a positive multiple of the block size %d", len(src), blockSize)
+	}
+	return int(src[len(src)-1]), nil
+}
+
+// unpadCount strips n bytes announced by the last byte, after checking that
+// 1 <= n <= blockSize and that the other padding bytes satisfy ok.
+func unpadCount(src []byte, blockSize int, ok func(b byte, n int) bool) ([]byte, error) {
+	n, err := checkPadded(src, blockSize)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 || n > blockSize {
+		return nil, errors.Errorf("invalid padding length %d", n)
+	}
+	for _, b := range src[len(src)-n : len(src)-1] {
+		if !ok(b, n) {
+			return nil, errors.New("invalid padding bytes")
+		}
+	}
+	return src[:len(src)-n], nil
+}
+
+type pkcs7 struct{}
+
+func (pkcs7) Pad(src []byte, blockSize int) ([]byte, error) {
+	if blockSize < 1 || blockSize > 255 {
+		return nil, errors.Errorf("PKCS7 block size must be 1 to 255, got %d", blockSize)
+	}
+	dst, n, _ := pad(src, blockSize)
+	for i := len(src); i < len(dst); i++ {
+		dst[i] = byte(n)
+	}
+	return dst, nil
+}
+
+func (pkcs7) Unpad(src []byte, blockSize int) ([]byte, error) {
+	return unpadCount(src, blockSize, func(b byte, n int) bool { return int(b) == n })
+}
+
+type iso10126 struct{}
+
+func (iso10126) Pad(src []byte, blockSize int) ([]byte, error) {
+	if blockSize < 1 || blockSize > 255 {
+		return nil, errors.Errorf("ISO 10126 block size must be 1 to 255, got %d", blockSize)
+	}
+	dst, n, _ := pad(src, blockSize)
+	if _, err := io.ReadFull(rand.Reader, dst[len(src):len(dst)-1]); err != nil {
+		return nil, errors.Wrapf(err, "random padding failed")
+	}
+	dst[len(dst)-1] = byte(n)
+	return dst, nil
+}
+
+func (iso10126) Unpad(src []byte, blockSize int) ([]byte, error) {
+	return unpadCount(src, blockSize, func(byte, int) bool { return true })
+}
+
+type ansiX923 struct{}
+
+func (ansiX923) Pad(src []byte, blockSize int) ([]byte, error) {
+	if blockSize < 1 || blockSize > 255 {
+		return nil, errors.Errorf("ANSI X.923 block size must be 1 to 255, got %d", blockSize)
+	}
+	dst, n, _ := pad(src, blockSize)
+	dst[len(dst)-1] = byte(n)
+	return dst, nil
+}
+
+func (ansiX923) Unpad(src []byte, blockSize int) ([]byte, error) {
+	return unpadCount(src, blockSize, func(b byte, _ int) bool { return b == 0 })
+}
+
+type iso7816 struct{}
+
+func (iso7816) Pad(src []byte, blockSize int) ([]byte, error) {
+	dst, _, err := pad(src, blockSize)
+	if err != nil {
+		return nil, err
+	}
+	dst[len(src)] = 0x80
+	return dst, nil
+}
+
+func (iso7816) Unpad(src []byte, blockSize int) ([]byte, error) {
+	if _, err := checkPadded(src, blockSize); err != nil {
+		return nil, err
+	}
+	for i := len(src) - 1; i >= len(src)-blockSize; i-- {
+		switch src[i] {
+		case 0:
+		case 0x80:
+			return src[:i], nil
+		default:
+			return nil, errors.New("invalid padding bytes")
+		}
+	}
+	return nil, errors.New("padding marker not found in the last block")
+}
+
+type zeroPadding struct{}
+
+func (zeroPadding) Pad(src []byte, blockSize int) ([]byte, error) {
+	dst, _, err := pad(src, blockSize)
+	return dst, err
+}
+
+func (zeroPadding) Unpad(src []byte, blockSize int) ([]byte, error) {
+	if _, err := checkPadded(src, blockSize); err != nil {
+		return nil, err
+	}
+	return ZeroUnPadding(src), nil
+}
+
+type noPadding struct{}
+
+func (noPadding) Pad(src []byte, blockSize int) ([]byte, error) {
+	if err := checkBlockSize(blockSize); err != nil {
+		return nil, err
+	}
+	if len(src)%blockSize != 0 {
+		return nil, errors.Errorf("length %d is not a multiple of the block size %d", len(src), blockSize)
+	}
+	return append([]byte{}, src...), nil
+}
+
+func (noPadding) Unpad(src []byte, blockSize int) ([]byte, error) {
+	if err := checkBlockSize(blockSize); err != nil {
+		return nil, err
+	}
+	if len(src)%blockSize != 0 {
+		return nil, errors.Errorf("length %d is not a multiple of the block size %d", len(src), blockSize)
+	}
+	return src, nil
+}
@@ -0,0 +1,123 @@
+package crypto
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPadding(t *testing.T) {
+	type Case struct {
+		padding Padding
+		src     []byte
+		padded  []byte
+	}
+
+	var (
+		cases = []Case{
+			{PKCS7, []byte{1, 2, 3}, []byte{1, 2, 3, 5, 5, 5, 5, 5}},
+			{PKCS7, []byte{1, 2, 3, 4, 5, 6, 7, 8}, []byte{1, 2, 3, 4, 5, 6, 7, 8, 8, 8, 8, 8, 8, 8, 8, 8}},
+			{ANSIX923, []byte{1, 2, 3}, []byte{1, 2, 3, 0, 0, 0, 0, 5}},
+			{ISO7816, []byte{1, 2, 3}, []byte{1, 2, 3, 0x80, 0, 0, 0, 0}},
+			{ISO7816, []byte{1, 2, 3, 4, 5, 6, 7}, []byte{1, 2, 3, 4, 5, 6, 7, 0x80}},
+			{ZeroPad, []byte{0, 1, 2}, []byte{0, 1, 2, 0, 0, 0, 0, 0}},
+			{NoPadding, []byte{1, 2, 3, 4, 5, 6, 7, 8}, []byte{1, 2, 3, 4, 5, 6, 7, 8}},
+		}
+	)
+
+	Convey("TEST Padding", t, func() {
+		for _, c := range cases {
+			padded, err := c.padding.Pad(c.src, 8)
+			So(err, ShouldBeNil)
+			So(padded, ShouldResemble, c.padded)
+
+			src, err := c.padding.Unpad(padded, 8)
+			So(err, ShouldBeNil)
+			So(src, ShouldResemble, c.src)
+		}
+	})
+
+	Convey("TEST Padding does not modify src", t, func() {
+		src := make([]byte, 3, 16)
+		_, _ = PKCS7.Pad(src, 8)
+		So(src[:4], ShouldResemble, []byte{0, 0, 0, 0})
+	})
+
+	Convey("TEST PKCS7 with 16-byte blocks", t, func() {
+		padded, _ := PKCS7.Pad([]byte("0123456789"), 16)
+		So(padded[10:], ShouldResemble, []byte{6, 6, 6, 6, 6, 6})
+	})
+
+	Convey("TEST ISO10126", t, func() {
+		padded, err := ISO10126.Pad([]byte{1, 2, 3}, 8)
+		So(err, ShouldBeNil)
+		So(padded, ShouldHaveLength, 8)
+		So(padded[7], ShouldEqual, 5)
+
+		src, err := ISO10126.Unpad([]byte{1, 2, 3, 9, 8, 7, 6, 5}, 8)
+		So(err, ShouldBeNil)
+		So(src, ShouldResemble, []byte{1, 2, 3})
+	})
+
+	Convey("TEST ZeroPad only trims the trailing side", t, func() {
+		src, err := ZeroPad.Unpad([]byte{0, 0, 1, 0, 2, 0, 0, 0}, 8)
+		So(err, ShouldBeNil)
+		So(src, ShouldResemble, []byte{0, 0, 1, 0, 2})
+		So(ZeroUnPadding([]byte{0, 1, 0}), ShouldResemble, []byte{0, 1})
+	})
+
+	Convey("TEST Unpad rejects malformed padding", t, func() {
+		for _, c := range []Case{
+			{PKCS7, nil, nil},
+			{PKCS7, nil, []byte{1, 2, 3, 5, 5, 5, 4, 5}},
+			{PKCS7, nil, []byte{1, 2, 3, 4, 5, 6, 7, 0}},
+			{PKCS7, nil, []byte{1, 2, 3, 4, 5, 6, 7}},
+			{ANSIX923, nil, []byte{1, 2, 3, 0, 1, 0, 0, 5}},
+			{ANSIX923, nil, []byte{1, 2, 3, 0, 0, 0, 0, 9}},
+			{ISO10126, nil, []byte{1, 2, 3, 0, 0, 0, 0, 0}},
+			{ISO7816, nil, []byte{1, 2, 3, 0x80, 0, 1, 0, 0}},
+			{ISO7816, nil, []byte{0x80, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}},
+			{NoPadding, nil, []byte{1, 2, 3}},
+		} {
+			_, err := c.padding.Unpad(c.padded, 8)
+			So(err, ShouldNotBeNil)
+		}
+
+		_, err := NoPadding.Pad([]byte{1, 2, 3}, 8)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("TEST Padding rejects block size 0", t, func() {
+		for _, p := range []Padding{PKCS7, ISO10126, ANSIX923, ISO7816, ZeroPad, NoPadding} {
+			_, err := p.Pad([]byte{1, 2, 3}, 0)
+			So(err, ShouldNotBeNil)
+			_, err = p.Unpad([]byte{1, 2, 3}, 0)
+			So(err, ShouldNotBeNil)
+		}
+	})
+
+	Convey("TEST BlockCipher paddings", t, func() {
+		for _, padmode := range []uint8{PAD_NORMAL, PAD_PKCS5, PAD_ISO10126, PAD_ANSIX923, PAD_ISO7816} {
+			d, err := NewDes([]byte("TANGTANG"), CBC, []byte("12345678"), padmode)
+			So(err, ShouldBeNil)
+			So(d.Decrypt(d.Encrypt([]byte("payload"))), ShouldResemble, []byte("payload"))
+		}
+
+		d, _ := NewDes([]byte("TANGTANG"), ECB, nil, PAD_NONE)
+		_, err := d.Seal([]byte("payload"), nil)
+		So(err, ShouldNotBeNil)
+		So(d.Decrypt(d.Encrypt([]byte("8 bytes!"))), ShouldResemble, []byte("8 bytes!"))
+
+		// Switching padding after construction; ISO 7816 keeps trailing zeros
+		d.SetPadding(ISO7816)
+		So(d.Decrypt(d.Encrypt([]byte{1, 0, 0})), ShouldResemble, []byte{1, 0, 0})
+
+		// Unknown padding modes zero pad
+		for _, padmode := range []uint8{0, 9} {
+			d, err = NewDes([]byte("TANGTANG"), ECB, nil, padmode)
+			So(err, ShouldBeNil)
+			z, _ := NewDes([]byte("TANGTANG"), ECB, nil, PAD_NORMAL)
+			So(d.Encrypt([]byte("payload")), ShouldResemble, z.Encrypt([]byte("payload")))
+		}
+	})
+}
@@ -0,0 +1,193 @@
+package crypto
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// Compressions of a Pipeline, also its flag byte values
+	COMPRESS_NONE  = 0
+	COMPRESS_ZLIB  = 1
+	COMPRESS_GZIP  = 2
+	COMPRESS_FLATE = 3
+
+	// DefaultMaxSize is the payload size limit of a Pipeline without MaxSize.
+	DefaultMaxSize = 16 << 20
+
+	// LEVEL_NO_COMPRESSION is the Level for flate.NoCompression, which 0
+	// cannot select as it means the default level.
+	LEVEL_NO_COMPRESSION = -3
+)
+
+// Pipeline compresses payloads and then encrypts them, and reverses that on
+// Open. The frame under the cipher is a flag byte, the COMPRESS_* value
+// actually used, followed by the payload. Payloads under Threshold bytes, or
+// that do not shrink, are sent as they are. Open accepts any of the
+// compressions whatever Compression is set to.
+type Pipeline struct {
+	// Cipher encrypts the frame; nil sends it in the clear.
+	Cipher Cipher
+	// Compression is one of COMPRESS_*.
+	Compression uint8
+	// Level is the compression level, 0 for the default and
+	// LEVEL_NO_COMPRESSION for none.
+	Level int
+	// Threshold is the smallest payload that is compressed.
+	Threshold int
+	// MaxSize limits the payload size, compressed or not, to protect from
+	// decompression bombs; 0 means DefaultMaxSize.
+	MaxSize int64
+}
+
+var _ Cipher = (*Pipeline)(nil)
+
+func (p *Pipeline) compress(payload []byte) (out []byte, err error) {
+	var (
+		buf   bytes.Buffer
+		w     io.WriteCloser
+		level = p.Level
+	)
+
+	switch level {
+	case 0:
+		level = flate.DefaultCompression
+	case LEVEL_NO_COMPRESSION:
+		level = flate.NoCompression
+	}
+
+	switch p.Compression {
+	case COMPRESS_ZLIB:
+		w, err = zlib.NewWriterLevel(&buf, level)
+	case COMPRESS_GZIP:
+		w, err = gzip.NewWriterLevel(&buf, level)
+	case COMPRESS_FLATE:
+		w, err = flate.NewWriter(&buf, level)
+	default:
+		return nil, errors.Errorf("unsupported compression %d", p.Compression)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid compression level %d", p.Level)
+	}
+
+	if _, err = w.Write(payload); err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "compression failed")
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (p *Pipeline) decompress(flag byte, body []byte) (payload []byte, err error) {
+	var (
+		r   io.ReadCloser
+		max = p.maxSize()
+	)
+
+	switch flag {
+	case COMPRESS_ZLIB:
+		r, err = zlib.NewReader(bytes.NewReader(body))
+	case COMPRESS_GZIP:
+		r, err = gzip.NewReader(bytes.NewReader(body))
+	case COMPRESS_FLATE:
+		r = flate.NewReader(bytes.NewReader(body))
+	default:
+		return nil, errors.Errorf("unknown compression flag %d", flag)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "decompression failed")
+	}
+	defer r.Close()
+
+	if payload, err = ioutil.ReadAll(io.LimitReader(r, max+1)); err != nil {
+		return nil, errors.Wrapf(err, "decompression failed")
+	}
+	if int64(len(payload)) > max {
+		return nil, errors.Errorf("decompressed payload exceeds %d bytes", max)
+	}
+
+	return payload, nil
+}
+
+func (p *Pipeline) maxSize() int64 {
+	if p.MaxSize == 0 {
+		return DefaultMaxSize
+	}
+	return p.MaxSize
+}
+
+func (p *Pipeline) Encrypt(payload []byte) (data []byte) {
+	data, _ = p.Seal(payload, nil)
+	return
+}
+
+func (p *Pipeline) Decrypt(data []byte) (payload []byte) {
+	payload, _ = p.Open(data, nil)
+	return
+}
+
+// Seal frames, compresses and encrypts payload. It fails on an unsupported
+// Compression, whatever the payload size, and on payloads over MaxSize,
+// which the peer would refuse to Open.
+func (p *Pipeline) Seal(payload, additionalData []byte) (data []byte, err error) {
+	var (
+		frame      []byte
+		compressed []byte
+	)
+
+	switch p.Compression {
+	case COMPRESS_NONE, COMPRESS_ZLIB, COMPRESS_GZIP, COMPRESS_FLATE:
+	default:
+		return nil, errors.Errorf("unsupported compression %d", p.Compression)
+	}
+	if max := p.maxSize(); int64(len(payload)) > max {
+		return nil, errors.Errorf("payload exceeds %d bytes", max)
+	}
+
+	frame = append([]byte{COMPRESS_NONE}, payload...)
+	if p.Compression != COMPRESS_NONE && len(payload) >= p.Threshold {
+		if compressed, err = p.compress(payload); err != nil {
+			return
+		}
+		if len(compressed) < len(payload) {
+			frame = append([]byte{p.Compression}, compressed...)
+		}
+	}
+
+	if p.Cipher == nil {
+		return frame, nil
+	}
+
+	return p.Cipher.Seal(frame, additionalData)
+}
+
+// Open decrypts and decompresses data.
+func (p *Pipeline) Open(data, additionalData []byte) (payload []byte, err error) {
+	var frame = data
+
+	if p.Cipher != nil {
+		if frame, err = p.Cipher.Open(data, additionalData); err != nil {
+			return
+		}
+	}
+
+	if len(frame) == 0 {
+		return nil, errors.New("empty frame")
+	}
+	if frame[0] == COMPRESS_NONE {
+		if max := p.maxSize(); int64(len(frame)-1) > max {
+			return nil, errors.Errorf("payload exceeds %d bytes", max)
+		}
+		return frame[1:], nil
+	}
+
+	return p.decompress(frame[0], frame[1:])
+}
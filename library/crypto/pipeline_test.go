@@ -0,0 +1,113 @@
+package crypto
+
+import (
+	"bytes"
+	"compress/zlib"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPipeline(t *testing.T) {
+	var (
+		des, _  = NewDes([]byte("TANGTANG"), CBC, []byte("12345678"), PAD_PKCS5)
+		large   = bytes.Repeat([]byte("a highly compressible payload "), 100)
+		payload = []byte("short")
+	)
+
+	Convey("TEST Pipeline round trip", t, func() {
+		for _, compression := range []uint8{COMPRESS_NONE, COMPRESS_ZLIB, COMPRESS_GZIP, COMPRESS_FLATE} {
+			p := &Pipeline{Cipher: des, Compression: compression, Threshold: 64}
+
+			for _, pl := range [][]byte{payload, large} {
+				data, err := p.Seal(pl, nil)
+				So(err, ShouldBeNil)
+				got, err := p.Open(data, nil)
+				So(err, ShouldBeNil)
+				So(got, ShouldResemble, pl)
+			}
+
+			frame := des.Decrypt(p.Encrypt(large))
+			So(frame[0], ShouldEqual, compression)
+			if compression != COMPRESS_NONE {
+				So(len(frame), ShouldBeLessThan, len(large)/10)
+			}
+
+			// Under the threshold nothing is compressed
+			So(des.Decrypt(p.Encrypt(payload)), ShouldResemble, append([]byte{COMPRESS_NONE}, payload...))
+		}
+	})
+
+	Convey("TEST Pipeline frame format", t, func() {
+		p := &Pipeline{Compression: COMPRESS_ZLIB}
+		frame := p.Encrypt(large)
+		So(frame[0], ShouldEqual, COMPRESS_ZLIB)
+
+		r, _ := zlib.NewReader(bytes.NewReader(frame[1:]))
+		var buf bytes.Buffer
+		_, _ = buf.ReadFrom(r)
+		So(buf.Bytes(), ShouldResemble, large)
+
+		// Incompressible payloads are sent as they are
+		So(p.Encrypt([]byte{1, 2, 3}), ShouldResemble, []byte{COMPRESS_NONE, 1, 2, 3})
+
+		// The receiver follows the flag, not its own setting
+		So((&Pipeline{Compression: COMPRESS_GZIP}).Decrypt(frame), ShouldResemble, large)
+	})
+
+	Convey("TEST Pipeline decompression limit", t, func() {
+		bomb := (&Pipeline{Compression: COMPRESS_FLATE}).Encrypt(make([]byte, 1<<20))
+		So(len(bomb), ShouldBeLessThan, 2048)
+
+		_, err := (&Pipeline{MaxSize: 1<<20 - 1}).Open(bomb, nil)
+		So(err, ShouldNotBeNil)
+		got, err := (&Pipeline{MaxSize: 1 << 20}).Open(bomb, nil)
+		So(err, ShouldBeNil)
+		So(got, ShouldHaveLength, 1<<20)
+
+		_, err = (&Pipeline{MaxSize: 4}).Open([]byte{COMPRESS_NONE, 1, 2, 3, 4, 5}, nil)
+		So(err, ShouldNotBeNil)
+
+		// Uncompressed frames fall back to DefaultMaxSize too
+		_, err = (&Pipeline{}).Open(append([]byte{COMPRESS_NONE}, make([]byte, DefaultMaxSize+1)...), nil)
+		So(err, ShouldNotBeNil)
+		got, err = (&Pipeline{}).Open(append([]byte{COMPRESS_NONE}, make([]byte, DefaultMaxSize)...), nil)
+		So(err, ShouldBeNil)
+		So(got, ShouldHaveLength, DefaultMaxSize)
+	})
+
+	Convey("TEST Pipeline compression levels", t, func() {
+		for _, c := range []uint8{COMPRESS_ZLIB, COMPRESS_GZIP, COMPRESS_FLATE} {
+			stored, err := (&Pipeline{Compression: c, Level: LEVEL_NO_COMPRESSION}).compress(large)
+			So(err, ShouldBeNil)
+			So(len(stored), ShouldBeGreaterThan, len(large))
+			compressed, err := (&Pipeline{Compression: c}).compress(large)
+			So(err, ShouldBeNil)
+			So(len(compressed), ShouldBeLessThan, len(large))
+		}
+	})
+
+	Convey("TEST Pipeline errors", t, func() {
+		_, err := (&Pipeline{}).Open(nil, nil)
+		So(err, ShouldNotBeNil)
+		_, err = (&Pipeline{}).Open([]byte{9, 1, 2}, nil)
+		So(err, ShouldNotBeNil)
+		_, err = (&Pipeline{}).Open([]byte{COMPRESS_ZLIB, 1, 2}, nil)
+		So(err, ShouldNotBeNil)
+		_, err = (&Pipeline{Compression: 9}).Seal(large, nil)
+		So(err, ShouldNotBeNil)
+		// Even below Threshold, where nothing is compressed
+		_, err = (&Pipeline{Compression: 9, Threshold: 1 << 20}).Seal([]byte("small"), nil)
+		So(err, ShouldNotBeNil)
+		_, err = (&Pipeline{MaxSize: 4}).Seal([]byte("small"), nil)
+		So(err, ShouldNotBeNil)
+		_, err = (&Pipeline{Compression: COMPRESS_ZLIB}).Seal(make([]byte, DefaultMaxSize+1), nil)
+		So(err, ShouldNotBeNil)
+		_, err = (&Pipeline{MaxSize: 5}).Seal([]byte("small"), nil)
+		So(err, ShouldBeNil)
+		_, err = (&Pipeline{Compression: COMPRESS_ZLIB, Level: 42}).Seal(large, nil)
+		So(err, ShouldNotBeNil)
+		_, err = (&Pipeline{Cipher: des}).Open([]byte("garbage!"), nil)
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,215 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/pem"
+	"hash"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// Paddings of RSA key wrapping
+	RSA_PKCS1V15    = 1
+	RSA_OAEP        = 2 // OAEP with SHA-1, as older clients use
+	RSA_OAEP_SHA256 = 3
+)
+
+// pemOrDER returns the bytes of the first PEM block in data, or data itself
+// if it is not PEM.
+func pemOrDER(data []byte) []byte {
+	if block, _ := pem.Decode(data); block != nil {
+		return block.Bytes
+	}
+	return data
+}
+
+// ParseRSAPublicKey reads a PEM or DER RSA public key, PKIX ("PUBLIC KEY")
+// or PKCS#1 ("RSA PUBLIC KEY").
+func ParseRSAPublicKey(data []byte) (pub *rsa.PublicKey, err error) {
+	var (
+		der = pemOrDER(data)
+		key interface{}
+		ok  bool
+	)
+
+	if pub, err = x509.ParsePKCS1PublicKey(der); err == nil {
+		return
+	}
+	if key, err = x509.ParsePKIXPublicKey(der); err != nil {
+		err = errors.Wrapf(err, "invalid RSA public key")
+		return
+	}
+	if pub, ok = key.(*rsa.PublicKey); !ok {
+		err = errors.Errorf("public key is %T, not RSA", key)
+	}
+
+	return
+}
+
+// ParseRSAPrivateKey reads a PEM or DER RSA private key, PKCS#1
+// ("RSA PRIVATE KEY") or PKCS#8 ("PRIVATE KEY").
+func ParseRSAPrivateKey(data []byte) (priv *rsa.PrivateKey, err error) {
+	var (
+		der = pemOrDER(data)
+		key interface{}
+		ok  bool
+	)
+
+	if priv, err = x509.ParsePKCS1PrivateKey(der); err == nil {
+		return
+	}
+	if key, err = x509.ParsePKCS8PrivateKey(der); err != nil {
+		err = errors.Wrapf(err, "invalid RSA private key")
+		return
+	}
+	if priv, ok = key.(*rsa.PrivateKey); !ok {
+		err = errors.Errorf("private key is %T, not RSA", key)
+	}
+
+	return
+}
+
+func oaepHash(padding uint8) (h hash.Hash, err error) {
+	switch padding {
+	case RSA_OAEP:
+		return sha1.New(), nil
+	case RSA_OAEP_SHA256:
+		return sha256.New(), nil
+	}
+	return nil, errors.Errorf("unsupported RSA padding %d", padding)
+}
+
+// checkKeySize checks that keySize is at least 1 and no more than padding
+// lets an RSA key of k bytes wrap: k-11 for PKCS #1 v1.5, k-2*hLen-2 for OAEP.
+func checkKeySize(k int, padding uint8, keySize int) (err error) {
+	var (
+		h   hash.Hash
+		max = k - 11
+	)
+
+	if padding != RSA_PKCS1V15 {
+		if h, err = oaepHash(padding); err != nil {
+			return
+		}
+		max = k - 2*h.Size() - 2
+	}
+	if keySize < 1 || keySize > max {
+		err = errors.Errorf("key size must be 1 to %d bytes, got %d", max, keySize)
+	}
+
+	return
+}
+
+// WrapKey encrypts a symmetric key with the RSA public key.
+func WrapKey(pub *rsa.PublicKey, key []byte, padding uint8) (wrapped []byte, err error) {
+	var h hash.Hash
+
+	if padding == RSA_PKCS1V15 {
+		wrapped, err = rsa.EncryptPKCS1v15(rand.Reader, pub, key)
+	} else if h, err = oaepHash(padding); err == nil {
+		wrapped, err = rsa.EncryptOAEP(h, rand.Reader, pub, key, nil)
+	}
+	if err != nil {
+		err = errors.Wrapf(err, "wrapping key failed")
+	}
+
+	return
+}
+
+// UnwrapKey decrypts a keySize-byte symmetric key wrapped with the RSA public
+// key. So as not to be a padding oracle, PKCS #1 v1.5 does not report a
+// malformed or wrong-length key: it returns a random key instead, and the
+// failure shows when the peer's messages do not decrypt.
+func UnwrapKey(priv *rsa.PrivateKey, wrapped []byte, padding uint8, keySize int) (key []byte, err error) {
+	var h hash.Hash
+
+	if err = checkKeySize(priv.Size(), padding, keySize); err != nil {
+		return
+	}
+
+	if padding == RSA_PKCS1V15 {
+		key = make([]byte, keySize)
+		if _, err = rand.Read(key); err != nil {
+			err = errors.Wrapf(err, "generating key failed")
+			return
+		}
+		err = rsa.DecryptPKCS1v15SessionKey(nil, priv, wrapped, key)
+	} else if h, err = oaepHash(padding); err == nil {
+		if key, err = rsa.DecryptOAEP(h, nil, priv, wrapped, nil); err == nil && subtle.ConstantTimeEq(int32(len(key)), int32(keySize)) != 1 {
+			key, err = nil, errors.Errorf("wrapped key is not %d bytes", keySize)
+		}
+	}
+	if err != nil {
+		key, err = nil, errors.Wrapf(err, "unwrapping key failed")
+	}
+
+	return
+}
+
+// NewWrappedCipher is the client side of an RSA key bootstrap: it makes a
+// random keySize-byte key, builds the session cipher from it with newCipher
+// and wraps the key for the server.
+func NewWrappedCipher(pub *rsa.PublicKey, padding uint8, keySize int, newCipher func(key []byte) (Cipher, error)) (c Cipher, wrapped []byte, err error) {
+	var key []byte
+
+	if err = checkKeySize(pub.Size(), padding, keySize); err != nil {
+		return
+	}
+
+	key = make([]byte, keySize)
+	if _, err = rand.Read(key); err != nil {
+		err = errors.Wrapf(err, "generating key failed")
+		return
+	}
+	if wrapped, err = WrapKey(pub, key, padding); err != nil {
+		return
+	}
+	if c, err = newCipher(key); err != nil {
+		return
+	}
+
+	return
+}
+
+// NewWrappedDes is NewWrappedCipher for a single DES session: the random key
+// has odd parity and is never weak.
+func NewWrappedDes(pub *rsa.PublicKey, padding uint8, mode uint8, iv []byte, padmode uint8) (d *Des, wrapped []byte, err error) {
+	key := make([]byte, 8)
+
+	for {
+		if _, err = rand.Read(key); err != nil {
+			err = errors.Wrapf(err, "generating key failed")
+			return
+		}
+		if key = SetDesParity(key); CheckDesKey(key) == nil {
+			break
+		}
+	}
+
+	if wrapped, err = WrapKey(pub, key, padding); err != nil {
+		return
+	}
+	if d, err = NewDes(key, mode, iv, padmode); err != nil {
+		return
+	}
+
+	return
+}
+
+// UnwrapCipher is the server side of NewWrappedCipher and NewWrappedDes
+// (keySize 8).
+func UnwrapCipher(priv *rsa.PrivateKey, wrapped []byte, padding uint8, keySize int, newCipher func(key []byte) (Cipher, error)) (c Cipher, err error) {
+	var key []byte
+
+	if key, err = UnwrapKey(priv, wrapped, padding, keySize); err != nil {
+		return
+	}
+
+	return newCipher(key)
+}
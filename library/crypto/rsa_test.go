@@ -0,0 +1,128 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRSA(t *testing.T) {
+	var (
+		priv, _ = rsa.GenerateKey(rand.Reader, 2048)
+		pub     = &priv.PublicKey
+		newDes  = func(key []byte) (Cipher, error) {
+			return NewDes(key, CBC, []byte("12345678"), PAD_PKCS5)
+		}
+	)
+
+	Convey("TEST ParseRSAPublicKey and ParseRSAPrivateKey", t, func() {
+		pkix, _ := x509.MarshalPKIXPublicKey(pub)
+		pkcs8, _ := x509.MarshalPKCS8PrivateKey(priv)
+
+		for _, der := range [][]byte{pkix, x509.MarshalPKCS1PublicKey(pub)} {
+			for _, data := range [][]byte{der, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})} {
+				p, err := ParseRSAPublicKey(data)
+				So(err, ShouldBeNil)
+				So(p.Equal(pub), ShouldBeTrue)
+			}
+		}
+
+		for _, der := range [][]byte{pkcs8, x509.MarshalPKCS1PrivateKey(priv)} {
+			for _, data := range [][]byte{der, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})} {
+				p, err := ParseRSAPrivateKey(data)
+				So(err, ShouldBeNil)
+				So(p.Equal(priv), ShouldBeTrue)
+			}
+		}
+
+		_, err := ParseRSAPublicKey([]byte("not a key"))
+		So(err, ShouldNotBeNil)
+		_, err = ParseRSAPrivateKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("junk")}))
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("TEST WrapKey and UnwrapKey", t, func() {
+		for _, padding := range []uint8{RSA_PKCS1V15, RSA_OAEP, RSA_OAEP_SHA256} {
+			wrapped, err := WrapKey(pub, []byte("TANGTANG"), padding)
+			So(err, ShouldBeNil)
+			So(wrapped, ShouldHaveLength, 256)
+
+			key, err := UnwrapKey(priv, wrapped, padding, 8)
+			So(err, ShouldBeNil)
+			So(key, ShouldResemble, []byte("TANGTANG"))
+		}
+
+		wrapped, _ := WrapKey(pub, []byte("TANGTANG"), RSA_OAEP)
+		_, err := UnwrapKey(priv, wrapped, RSA_OAEP_SHA256, 8)
+		So(err, ShouldNotBeNil)
+		_, err = UnwrapKey(priv, wrapped[1:], RSA_OAEP, 8)
+		So(err, ShouldNotBeNil)
+		_, err = UnwrapKey(priv, wrapped, RSA_OAEP, 16)
+		So(err, ShouldNotBeNil)
+		_, err = WrapKey(pub, []byte("TANGTANG"), 9)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("TEST UnwrapKey with PKCS1v15 gives no padding oracle", t, func() {
+		wrapped, _ := WrapKey(pub, []byte("TANGTANG"), RSA_PKCS1V15)
+		corrupted := append([]byte{}, wrapped...)
+		corrupted[100] ^= 1
+
+		// A malformed or wrong-length key is replaced by a random one, not reported
+		for _, c := range [][]byte{corrupted, wrapped} {
+			k1, err := UnwrapKey(priv, c, RSA_PKCS1V15, 16)
+			So(err, ShouldBeNil)
+			So(k1, ShouldHaveLength, 16)
+			k2, _ := UnwrapKey(priv, c, RSA_PKCS1V15, 16)
+			So(k1, ShouldNotResemble, k2)
+		}
+		// Only a key size too large for the RSA key is an error
+		_, err := UnwrapKey(priv, wrapped, RSA_PKCS1V15, 250)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("TEST key sizes out of range", t, func() {
+		// A 2048-bit key wraps up to 245 bytes with PKCS1v15, 214 with
+		// OAEP SHA-1 and 190 with OAEP SHA-256
+		for _, c := range []struct {
+			padding uint8
+			max     int
+		}{{RSA_PKCS1V15, 245}, {RSA_OAEP, 214}, {RSA_OAEP_SHA256, 190}} {
+			for _, keySize := range []int{-1, 0, c.max + 1} {
+				_, err := UnwrapKey(priv, make([]byte, 256), c.padding, keySize)
+				So(err, ShouldNotBeNil)
+				_, _, err = NewWrappedCipher(pub, c.padding, keySize, newDes)
+				So(err, ShouldNotBeNil)
+			}
+
+			_, wrapped, err := NewWrappedCipher(pub, c.padding, c.max, func(key []byte) (Cipher, error) { return nil, nil })
+			So(err, ShouldBeNil)
+			key, err := UnwrapKey(priv, wrapped, c.padding, c.max)
+			So(err, ShouldBeNil)
+			So(key, ShouldHaveLength, c.max)
+		}
+
+		_, err := UnwrapKey(priv, make([]byte, 256), 9, 8)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("TEST key bootstrap", t, func() {
+		c, wrapped, err := NewWrappedCipher(pub, RSA_OAEP, 8, newDes)
+		So(err, ShouldBeNil)
+		s, err := UnwrapCipher(priv, wrapped, RSA_OAEP, 8, newDes)
+		So(err, ShouldBeNil)
+		So(s.Decrypt(c.Encrypt([]byte("login"))), ShouldResemble, []byte("login"))
+
+		d, wrapped, err := NewWrappedDes(pub, RSA_PKCS1V15, CBC, []byte("12345678"), PAD_PKCS5)
+		So(err, ShouldBeNil)
+		key, _ := UnwrapKey(priv, wrapped, RSA_PKCS1V15, 8)
+		So(key, ShouldResemble, SetDesParity(key))
+		So(CheckDesKey(key), ShouldBeNil)
+		s, _ = UnwrapCipher(priv, wrapped, RSA_PKCS1V15, 8, newDes)
+		So(s.Decrypt(d.Encrypt([]byte("login"))), ShouldResemble, []byte("login"))
+	})
+}
@@ -0,0 +1,215 @@
+package crypto
+
+import (
+	"crypto/cipher"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// chunkSize is how much ciphertext a decrypting reader reads at a time.
+const chunkSize = 32 * 1024
+
+var errClosed = errors.New("write to closed encrypt writer")
+
+type encryptWriter struct {
+	w   io.Writer
+	c   *BlockCipher
+	bm  cipher.BlockMode
+	s   cipher.Stream
+	buf []byte
+	err error
+}
+
+// NewEncryptWriter returns a writer that encrypts what is written to it and
+// writes the ciphertext to w, a block at a time. The output is the same as
+// c.Encrypt of all the data, random IV included. Close pads and writes the
+// last block; it does not close w. GCM cannot be streamed.
+func NewEncryptWriter(w io.Writer, c *BlockCipher) (wc io.WriteCloser, err error) {
+	var (
+		iv     []byte
+		random bool
+	)
+
+	if c.mode == GCM {
+		err = errors.New("GCM cannot be streamed")
+		return
+	}
+
+	if iv, random, err = c.sealIV(); err != nil {
+		return
+	}
+
+	if random {
+		if _, err = w.Write(iv); err != nil {
+			err = errors.Wrapf(err, "writing IV failed")
+			return
+		}
+	}
+
+	wc = &encryptWriter{
+		w:  w,
+		c:  c,
+		bm: c.blockMode(false, iv),
+		s:  c.stream(false, iv),
+	}
+
+	return
+}
+
+func (e *encryptWriter) Write(p []byte) (n int, err error) {
+	var out []byte
+
+	if e.err != nil {
+		return 0, e.err
+	}
+
+	if e.s != nil {
+		out = make([]byte, len(p))
+		e.s.XORKeyStream(out, p)
+	} else {
+		e.buf = append(e.buf, p...)
+		full := len(e.buf) - len(e.buf)%e.bm.BlockSize()
+		out = make([]byte, full)
+		e.bm.CryptBlocks(out, e.buf[:full])
+		e.buf = append(e.buf[:0], e.buf[full:]...)
+	}
+
+	if len(out) > 0 {
+		if _, e.err = e.w.Write(out); e.err != nil {
+			return 0, e.err
+		}
+	}
+
+	return len(p), nil
+}
+
+func (e *encryptWriter) Close() (err error) {
+	var padded []byte
+
+	if e.err != nil {
+		return e.err
+	}
+	e.err = errClosed
+
+	if e.s != nil {
+		return
+	}
+
+	if padded, err = e.c.pad.Pad(e.buf, e.bm.BlockSize()); err != nil {
+		return
+	}
+
+	e.bm.CryptBlocks(padded, padded)
+	if len(padded) > 0 {
+		_, err = e.w.Write(padded)
+	}
+
+	return
+}
+
+type decryptReader struct {
+	r   io.Reader
+	c   *BlockCipher
+	bm  cipher.BlockMode
+	s   cipher.Stream
+	in  []byte
+	out []byte
+	err error
+}
+
+// NewDecryptReader returns a reader that decrypts what c.Encrypt or an
+// encrypt writer produced, reading the ciphertext from r. The last block is
+// held back until r reaches EOF so that its padding can be checked and
+// stripped. GCM cannot be streamed.
+func NewDecryptReader(r io.Reader, c *BlockCipher) (rd io.Reader, err error) {
+	var (
+		n  = c.ivSize()
+		iv = c.iv
+	)
+
+	if c.mode == GCM {
+		err = errors.New("GCM cannot be streamed")
+		return
+	}
+
+	if c.rand != nil && n > 0 {
+		iv = make([]byte, n)
+		if _, err = io.ReadFull(r, iv); err != nil {
+			err = errors.Wrapf(err, "reading IV failed")
+			return
+		}
+	} else if iv == nil && n > 0 {
+		err = errNoIV
+		return
+	}
+
+	rd = &decryptReader{
+		r:  r,
+		c:  c,
+		bm: c.blockMode(true, iv),
+		s:  c.stream(true, iv),
+	}
+
+	return
+}
+
+func (d *decryptReader) Read(p []byte) (n int, err error) {
+	for len(d.out) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		d.fill()
+	}
+
+	n = copy(p, d.out)
+	d.out = d.out[n:]
+
+	return
+}
+
+// fill reads a chunk of ciphertext and decrypts as much of it as it can.
+func (d *decryptReader) fill() {
+	var (
+		buf    = make([]byte, chunkSize)
+		n, err = d.r.Read(buf)
+	)
+
+	if d.s != nil {
+		d.out = make([]byte, n)
+		d.s.XORKeyStream(d.out, buf[:n])
+		d.err = err
+		return
+	}
+
+	d.in = append(d.in, buf[:n]...)
+	bs := d.bm.BlockSize()
+
+	if err == io.EOF {
+		if len(d.in)%bs != 0 {
+			d.err = errors.Errorf("ciphertext length is not a multiple of the block size %d", bs)
+			return
+		}
+		d.bm.CryptBlocks(d.in, d.in)
+		if d.out, d.err = d.c.pad.Unpad(d.in, bs); d.err == nil {
+			d.err = io.EOF
+		}
+		d.in = nil
+		return
+	}
+	if err != nil {
+		d.err = err
+		return
+	}
+
+	// The last whole block may be the padded one
+	keep := len(d.in) % bs
+	if keep == 0 {
+		keep = bs
+	}
+	if full := len(d.in) - keep; full > 0 {
+		d.out = make([]byte, full)
+		d.bm.CryptBlocks(d.out, d.in[:full])
+		d.in = append(d.in[:0], d.in[full:]...)
+	}
+}
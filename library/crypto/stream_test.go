@@ -0,0 +1,112 @@
+package crypto
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"testing"
+	"testing/iotest"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// encryptChunks writes plaintext to an encrypt writer in uneven chunks.
+func encryptChunks(c *BlockCipher, plaintext []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	w, err := NewEncryptWriter(&buf, c)
+	if err != nil {
+		return nil, err
+	}
+	for i, n := 0, 1; i < len(plaintext); i, n = i+n, n*3+1 {
+		if i+n > len(plaintext) {
+			n = len(plaintext) - i
+		}
+		if _, err = w.Write(plaintext[i : i+n]); err != nil {
+			return nil, err
+		}
+	}
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func TestStream(t *testing.T) {
+	var (
+		key, _    = hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c")
+		iv, _     = hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+		plaintext = make([]byte, 70000)
+	)
+
+	for i := range plaintext {
+		plaintext[i] = byte(i * 7)
+	}
+
+	Convey("TEST stream matches Encrypt and Decrypt", t, func() {
+		for _, mode := range []uint8{ECB, CBC, CFB, OFB, CTR} {
+			c, err := NewAes(key, mode, iv, PAD_PKCS5)
+			So(err, ShouldBeNil)
+
+			for _, n := range []int{0, 1, 15, 16, 17, 100, len(plaintext)} {
+				ciphertext, err := encryptChunks(c, plaintext[:n])
+				So(err, ShouldBeNil)
+				So(bytes.Equal(ciphertext, c.Encrypt(plaintext[:n])), ShouldBeTrue)
+
+				r, err := NewDecryptReader(iotest.HalfReader(bytes.NewReader(ciphertext)), c)
+				So(err, ShouldBeNil)
+				got, err := ioutil.ReadAll(r)
+				So(err, ShouldBeNil)
+				So(got, ShouldResemble, plaintext[:n])
+			}
+		}
+	})
+
+	Convey("TEST stream with random IV", t, func() {
+		d, _ := NewDes([]byte("TANGTANG"), CBC|RANDOM_IV, nil, PAD_PKCS5)
+		d.SetRandomIV(bytes.NewReader([]byte("12345678")))
+
+		ciphertext, err := encryptChunks(d, plaintext[:1000])
+		So(err, ShouldBeNil)
+		So(ciphertext[:8], ShouldResemble, []byte("12345678"))
+		So(d.Decrypt(ciphertext), ShouldResemble, plaintext[:1000])
+
+		r, err := NewDecryptReader(iotest.OneByteReader(bytes.NewReader(ciphertext)), d)
+		So(err, ShouldBeNil)
+		got, err := ioutil.ReadAll(r)
+		So(err, ShouldBeNil)
+		So(got, ShouldResemble, plaintext[:1000])
+	})
+
+	Convey("TEST stream errors", t, func() {
+		c, _ := NewAes(key, CBC, iv, PAD_PKCS5)
+		ciphertext := c.Encrypt(plaintext[:100])
+
+		for _, bad := range [][]byte{nil, ciphertext[:len(ciphertext)-1], ciphertext[:len(ciphertext)-16]} {
+			r, _ := NewDecryptReader(bytes.NewReader(bad), c)
+			_, err := ioutil.ReadAll(r)
+			So(err, ShouldNotBeNil)
+		}
+
+		w, _ := NewEncryptWriter(ioutil.Discard, c)
+		So(w.Close(), ShouldBeNil)
+		_, err := w.Write([]byte("late"))
+		So(err, ShouldNotBeNil)
+
+		n, _ := NewAes(key, ECB, nil, PAD_NONE)
+		w, _ = NewEncryptWriter(ioutil.Discard, n)
+		_, _ = w.Write([]byte("not a whole block"))
+		So(w.Close(), ShouldNotBeNil)
+
+		g, _ := NewAes(key, GCM, nil, PAD_PKCS5)
+		_, err = NewEncryptWriter(ioutil.Discard, g)
+		So(err, ShouldNotBeNil)
+		_, err = NewDecryptReader(bytes.NewReader(nil), g)
+		So(err, ShouldNotBeNil)
+
+		_, err = NewDecryptReader(iotest.ErrReader(io.ErrUnexpectedEOF), c)
+		So(err, ShouldBeNil)
+	})
+}
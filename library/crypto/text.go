@@ -0,0 +1,100 @@
+package crypto
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// Text encodings of ciphertext
+	TEXT_HEX           = 1 // lower case hex; decoding takes either case
+	TEXT_HEX_UPPER     = 2 // upper case hex; decoding takes either case
+	TEXT_BASE64        = 3 // standard base64 with = padding
+	TEXT_BASE64_RAW    = 4 // standard base64 without padding
+	TEXT_BASE64URL     = 5 // URL-safe base64 with = padding
+	TEXT_BASE64URL_RAW = 6 // URL-safe base64 without padding
+)
+
+var base64Encodings = map[uint8]*base64.Encoding{
+	TEXT_BASE64:        base64.StdEncoding.Strict(),
+	TEXT_BASE64_RAW:    base64.RawStdEncoding.Strict(),
+	TEXT_BASE64URL:     base64.URLEncoding.Strict(),
+	TEXT_BASE64URL_RAW: base64.RawURLEncoding.Strict(),
+}
+
+// EncodeText encodes data in one of the TEXT_* encodings.
+func EncodeText(data []byte, encoding uint8) (string, error) {
+	switch encoding {
+	case TEXT_HEX:
+		return hex.EncodeToString(data), nil
+	case TEXT_HEX_UPPER:
+		return strings.ToUpper(hex.EncodeToString(data)), nil
+	}
+	if enc := base64Encodings[encoding]; enc != nil {
+		return enc.EncodeToString(data), nil
+	}
+	return "", errors.Errorf("unsupported text encoding %d", encoding)
+}
+
+// DecodeText decodes s strictly: padded base64 must be padded and raw base64
+// must not be, unused bits must be zero, and no whitespace or line breaks
+// are allowed.
+func DecodeText(s string, encoding uint8) (data []byte, err error) {
+	var enc *base64.Encoding
+
+	switch encoding {
+	case TEXT_HEX, TEXT_HEX_UPPER:
+		if data, err = hex.DecodeString(s); err != nil {
+			err = errors.Wrapf(err, "invalid hex")
+		}
+		return
+	}
+
+	if enc = base64Encodings[encoding]; enc == nil {
+		return nil, errors.Errorf("unsupported text encoding %d", encoding)
+	}
+	// The base64 decoder skips line breaks, even in strict mode
+	if i := strings.IndexAny(s, "\r\n"); i >= 0 {
+		return nil, errors.Errorf("invalid base64: line break at offset %d", i)
+	}
+	if data, err = enc.DecodeString(s); err != nil {
+		err = errors.Wrapf(err, "invalid base64")
+	}
+
+	return
+}
+
+// EncryptToString encrypts plaintext with c and encodes the ciphertext.
+func EncryptToString(c Cipher, plaintext []byte, encoding uint8) (s string, err error) {
+	var ciphertext []byte
+
+	if ciphertext, err = c.Seal(plaintext, nil); err != nil {
+		return
+	}
+
+	return EncodeText(ciphertext, encoding)
+}
+
+// DecryptString decodes s and decrypts it with c.
+func DecryptString(c Cipher, s string, encoding uint8) (plaintext []byte, err error) {
+	var ciphertext []byte
+
+	if ciphertext, err = DecodeText(s, encoding); err != nil {
+		return
+	}
+
+	return c.Open(ciphertext, nil)
+}
+
+// EncryptToString is Encrypt with the ciphertext in a TEXT_* encoding.
+func (c *BlockCipher) EncryptToString(plaintext []byte, encoding uint8) (string, error) {
+	return EncryptToString(c, plaintext, encoding)
+}
+
+// DecryptString is Decrypt of ciphertext in a TEXT_* encoding.
+func (c *BlockCipher) DecryptString(s string, encoding uint8) ([]byte, error) {
+	return DecryptString(c, s, encoding)
+}
@@ -0,0 +1,96 @@
+package crypto
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestText(t *testing.T) {
+	type Case struct {
+		encoding uint8
+		data     []byte
+		text     string
+	}
+
+	var (
+		cases = []Case{
+			{TEXT_HEX, []byte{0xfb, 0xff, 0x01}, "fbff01"},
+			{TEXT_HEX_UPPER, []byte{0xfb, 0xff, 0x01}, "FBFF01"},
+			{TEXT_BASE64, []byte{0xfb, 0xff}, "+/8="},
+			{TEXT_BASE64_RAW, []byte{0xfb, 0xff}, "+/8"},
+			{TEXT_BASE64URL, []byte{0xfb, 0xff}, "-_8="},
+			{TEXT_BASE64URL_RAW, []byte{0xfb, 0xff}, "-_8"},
+			{TEXT_BASE64, []byte{}, ""},
+		}
+	)
+
+	Convey("TEST EncodeText and DecodeText", t, func() {
+		for _, c := range cases {
+			text, err := EncodeText(c.data, c.encoding)
+			So(err, ShouldBeNil)
+			So(text, ShouldEqual, c.text)
+
+			data, err := DecodeText(c.text, c.encoding)
+			So(err, ShouldBeNil)
+			So(data, ShouldResemble, c.data)
+		}
+
+		// Hex decoding takes either case
+		data, err := DecodeText("FbFf01", TEXT_HEX)
+		So(err, ShouldBeNil)
+		So(data, ShouldResemble, []byte{0xfb, 0xff, 0x01})
+	})
+
+	Convey("TEST DecodeText is strict", t, func() {
+		for _, c := range []Case{
+			{TEXT_HEX, nil, "fbf"},
+			{TEXT_HEX_UPPER, nil, "FBFG"},
+			{TEXT_BASE64, nil, "+/8"},      // missing padding
+			{TEXT_BASE64_RAW, nil, "+/8="}, // unexpected padding
+			{TEXT_BASE64, nil, "-_8="},     // URL alphabet
+			{TEXT_BASE64URL, nil, "+/8="},
+			{TEXT_BASE64, nil, "+/9="}, // non-zero unused bits
+			{TEXT_BASE64, nil, "+/8=\n"},
+			{TEXT_BASE64_RAW, nil, "+/\r\n8"},
+			{TEXT_BASE64, nil, " +/8="},
+			{9, nil, "00"},
+		} {
+			_, err := DecodeText(c.text, c.encoding)
+			So(err, ShouldNotBeNil)
+		}
+
+		_, err := EncodeText(nil, 9)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("TEST EncryptToString and DecryptString", t, func() {
+		des, _ := NewDes([]byte("TANGTANG"), CBC, []byte("TANGTANG"), PAD_PKCS5)
+
+		for _, encoding := range []uint8{TEXT_HEX, TEXT_HEX_UPPER, TEXT_BASE64, TEXT_BASE64_RAW, TEXT_BASE64URL, TEXT_BASE64URL_RAW} {
+			s, err := des.EncryptToString([]byte("payload"), encoding)
+			So(err, ShouldBeNil)
+			text, _ := EncodeText(des.Encrypt([]byte("payload")), encoding)
+			So(s, ShouldEqual, text)
+
+			plaintext, err := des.DecryptString(s, encoding)
+			So(err, ShouldBeNil)
+			So(plaintext, ShouldResemble, []byte("payload"))
+		}
+
+		s, _ := des.EncryptToString([]byte("payload"), TEXT_HEX_UPPER)
+		_, err := des.DecryptString(s+"0", TEXT_HEX_UPPER)
+		So(err, ShouldNotBeNil)
+		_, err = des.DecryptString(s[:len(s)-2], TEXT_HEX_UPPER)
+		So(err, ShouldNotBeNil)
+
+		// Any Cipher works through the package functions
+		rc4, _ := NewRC4([]byte("TANGTANG"), nil)
+		peer, _ := NewRC4([]byte("TANGTANG"), nil)
+		s, err = EncryptToString(rc4, []byte("payload"), TEXT_BASE64URL_RAW)
+		So(err, ShouldBeNil)
+		plaintext, err := DecryptString(peer, s, TEXT_BASE64URL_RAW)
+		So(err, ShouldBeNil)
+		So(plaintext, ShouldResemble, []byte("payload"))
+	})
+}